@@ -0,0 +1,348 @@
+package jsonhal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// BaseURLResolver derives the base URL clients used to reach the service
+// from requests. Forwarded and X-Forwarded-Proto, X-Forwarded-Host and
+// X-Forwarded-Prefix headers are honoured only when they were set by
+// trusted proxies, so clients cannot spoof them
+type BaseURLResolver struct {
+	trusted []*net.IPNet
+}
+
+// NewBaseURLResolver returns a resolver trusting proxies with listed
+// addresses, either IP addresses or CIDR ranges such as "10.0.0.0/8"
+func NewBaseURLResolver(trustedProxies ...string) (*BaseURLResolver, error) {
+	resolver := new(BaseURLResolver)
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("Invalid trusted proxy \"%s\"", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			resolver.trusted = append(resolver.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid trusted proxy \"%s\": %w", proxy, err)
+		}
+		resolver.trusted = append(resolver.trusted, network)
+	}
+	return resolver, nil
+}
+
+// BaseURL returns the base URL of the request. When the request came
+// through trusted proxies the values set by the outermost trusted proxy
+// are used, the Forwarded header takes precedence over X-Forwarded-*
+// headers. The path of the base URL is the forwarded prefix, if any
+func (res *BaseURLResolver) BaseURL(r *http.Request) *url.URL {
+	base := &url.URL{Scheme: "http", Host: r.Host}
+	if r.TLS != nil {
+		base.Scheme = "https"
+	}
+	if !res.isTrusted(r.RemoteAddr) {
+		return base
+	}
+
+	var trusted int
+	if forwarded := r.Header.Values("Forwarded"); len(forwarded) > 0 {
+		elements := parseForwarded(forwarded)
+		hops := make([]string, len(elements))
+		for i, element := range elements {
+			hops[i] = element["for"]
+		}
+		trusted = res.trustedHops(hops)
+		// Elements appended by trusted proxies are used from the outermost
+		// one, later elements fill in parameters it does not set
+		var proto, host string
+		for i := len(elements) - trusted; i < len(elements); i++ {
+			if proto == "" {
+				proto = elements[i]["proto"]
+			}
+			if host == "" {
+				host = elements[i]["host"]
+			}
+		}
+		if proto != "" {
+			base.Scheme = strings.ToLower(proto)
+		}
+		if host != "" {
+			base.Host = host
+		}
+	} else {
+		trusted = res.trustedHops(headerList(r.Header.Values("X-Forwarded-For")))
+		if proto := forwardedValue(r.Header.Values("X-Forwarded-Proto"), trusted); proto != "" {
+			base.Scheme = strings.ToLower(proto)
+		}
+		if host := forwardedValue(r.Header.Values("X-Forwarded-Host"), trusted); host != "" {
+			base.Host = host
+		}
+	}
+	// Forwarded has no prefix parameter, X-Forwarded-Prefix is used with both
+	prefix := forwardedValue(r.Header.Values("X-Forwarded-Prefix"), trusted)
+	if strings.HasPrefix(prefix, "/") {
+		base.Path = strings.TrimSuffix(prefix, "/")
+	}
+	return base
+}
+
+// isTrusted reports whether the host of addr is a trusted proxy
+func (res *BaseURLResolver) isTrusted(addr string) bool {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	ip := net.ParseIP(strings.Trim(addr, "[]"))
+	if ip == nil {
+		return false
+	}
+	for _, network := range res.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedHops returns the number of hops appended by trusted proxies,
+// hops lists peers the proxies received the request from. The immediate
+// peer is trusted so its hop is, every further hop is trusted as long
+// as the peer it was received from is a trusted proxy
+func (res *BaseURLResolver) trustedHops(hops []string) int {
+	trusted := 1
+	for i := len(hops) - 1; i > 0 && res.isTrusted(hops[i]); i-- {
+		trusted++
+	}
+	return trusted
+}
+
+// forwardedValue returns the value of a X-Forwarded-* header set by the
+// outermost of trusted proxies, assuming every proxy appended a value
+func forwardedValue(values []string, trusted int) string {
+	list := headerList(values)
+	if len(list) == 0 {
+		return ""
+	}
+	index := len(list) - trusted
+	if index < 0 {
+		index = 0
+	}
+	return list[index]
+}
+
+func headerList(values []string) []string {
+	var list []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+// parseForwarded parses elements of RFC 7239 Forwarded headers
+func parseForwarded(values []string) []map[string]string {
+	var elements []map[string]string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			params := make(map[string]string)
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				params[strings.ToLower(key)] = strings.Trim(value, "\"")
+			}
+			elements = append(elements, params)
+		}
+	}
+	return elements
+}
+
+type baseURLKey struct{}
+
+// Middleware stores the base URL of every request in its context. Hrefs of
+// resources written by WriteResource and Negotiate are then resolved against
+// it, see ResolveHrefs
+func (res *BaseURLResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), baseURLKey{}, res.BaseURL(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// BaseURLFromContext returns the base URL stored by the middleware
+func BaseURLFromContext(ctx context.Context) (*url.URL, bool) {
+	base, ok := ctx.Value(baseURLKey{}).(*url.URL)
+	return base, ok
+}
+
+// ResolveHrefs rewrites relative hrefs of links against base, recursively
+// through embedded resources. Path-absolute hrefs such as "/v1/orders/1"
+// are prefixed with the base URL including its path, relative paths, queries
+// and fragments are resolved against the base URL as defined by RFC 3986 and
+// absolute URLs and hrefs starting with a template expression are left as
+// they are. Embedded resources are modified in place, Clone resources shared
+// with other goroutines or caches first
+func (h *Hal) ResolveHrefs(base *url.URL) {
+	for _, set := range h.Links {
+		for _, link := range set.Links {
+			link.Href = resolveBaseHref(base, link.Href)
+		}
+	}
+	for name, embedded := range h.Embedded {
+		if embedded != nil {
+			h.Embedded[name] = resolveValue(reflect.ValueOf(embedded), base).Interface()
+		}
+	}
+}
+
+// resolveValue resolves hrefs of resources embedding Hal in value,
+// struct values are copied as they cannot be modified in place
+func resolveValue(value reflect.Value, base *url.URL) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if !value.IsNil() && value.Type().Implements(halResourceType) {
+			resolveResource(value, base)
+		}
+	case reflect.Struct:
+		if reflect.PointerTo(value.Type()).Implements(halResourceType) {
+			copied := reflect.New(value.Type())
+			copied.Elem().Set(value)
+			resolveResource(copied, base)
+			return copied.Elem()
+		}
+	case reflect.Interface:
+		if !value.IsNil() {
+			resolved := reflect.New(value.Type()).Elem()
+			resolved.Set(resolveValue(value.Elem(), base))
+			return resolved
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if item := value.Index(i); item.CanSet() {
+				item.Set(resolveValue(item, base))
+			}
+		}
+	case reflect.Map:
+		if value.Type().Key().Kind() == reflect.String {
+			iter := value.MapRange()
+			for iter.Next() {
+				value.SetMapIndex(iter.Key(), resolveValue(iter.Value(), base))
+			}
+		}
+	}
+	return value
+}
+
+// resolveResource resolves hrefs of the resource ptr points to along with
+// fields tagged as embedded
+func resolveResource(ptr reflect.Value, base *url.URL) {
+	if h := ptr.Interface().(halResource).hal(); h != nil {
+		h.ResolveHrefs(base)
+	}
+	if ptr.Elem().Kind() == reflect.Struct && isTaggable(ptr.Type().Elem()) {
+		for _, embedded := range structTags(ptr.Type().Elem()).embedded {
+			if field, err := ptr.Elem().FieldByIndexErr(embedded.index); err == nil && field.CanSet() {
+				field.Set(resolveValue(field, base))
+			}
+		}
+	}
+}
+
+// resolveBaseHref resolves href against base, hrefs can be URI templates
+// so they are not parsed as URLs. Hrefs starting with a template expression
+// are left as they are since it is unknown what the expression expands to
+func resolveBaseHref(base *url.URL, href string) string {
+	if hasScheme(href) || strings.HasPrefix(href, "//") || strings.HasPrefix(href, "{") {
+		return href
+	}
+	origin := base.Scheme + "://" + base.Host
+	// Same document references keep the base path and query, RFC 3986 5.2.2
+	switch {
+	case href == "" || strings.HasPrefix(href, "#"):
+		if base.RawQuery != "" {
+			return origin + base.Path + "?" + base.RawQuery + href
+		}
+		return origin + base.Path + href
+	case strings.HasPrefix(href, "?"):
+		return origin + base.Path + href
+	}
+	if strings.HasPrefix(href, "/") {
+		return origin + removeDotSegments(strings.TrimSuffix(base.Path, "/")+href)
+	}
+	dir := base.Path[:strings.LastIndex(base.Path, "/")+1]
+	if dir == "" {
+		dir = "/"
+	}
+	return origin + removeDotSegments(dir+href)
+}
+
+// removeDotSegments removes "." and ".." segments from the path of href,
+// an absolute path reference, as defined by RFC 3986 5.2.4. The query,
+// fragment and anything after a template expression are left as they are
+func removeDotSegments(href string) string {
+	end := strings.IndexAny(href, "?#{")
+	if end < 0 {
+		end = len(href)
+	}
+	segments := strings.Split(href[1:end], "/")
+	kept := make([]string, 0, len(segments))
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		switch segment {
+		case ".":
+		case "..":
+			if len(kept) > 0 {
+				kept = kept[:len(kept)-1]
+			}
+		default:
+			kept = append(kept, segment)
+			continue
+		}
+		if last {
+			kept = append(kept, "")
+		}
+	}
+	return "/" + strings.Join(kept, "/") + href[end:]
+}
+
+// hasScheme reports whether href starts with a URI scheme
+func hasScheme(href string) bool {
+	for i := 0; i < len(href); i++ {
+		c := href[i]
+		switch {
+		case isAlpha(c):
+		case i > 0 && (isDigit(c) || c == '+' || c == '-' || c == '.'):
+		case i > 0 && c == ':':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// resolveForRequest returns a copy of v with hrefs resolved against
+// the base URL stored in the request context or v if there is none
+func resolveForRequest(r *http.Request, v interface{}) interface{} {
+	base, ok := BaseURLFromContext(r.Context())
+	if !ok || v == nil {
+		return v
+	}
+	return resolveValue(cloneValue(reflect.ValueOf(v)), base).Interface()
+}
@@ -0,0 +1,176 @@
+package jsonhal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBaseURL(t *testing.T) {
+	resolver, err := NewBaseURLResolver("10.0.0.0/8", "192.168.1.1", "::1")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		remoteAddr string
+		header     http.Header
+		expected   string
+	}{
+		// Headers of untrusted peers are ignored
+		{"1.2.3.4:1234", http.Header{"X-Forwarded-Host": {"evil.com"}}, "http://example.com"},
+		{"1.2.3.4:1234", http.Header{"Forwarded": {"host=evil.com"}}, "http://example.com"},
+		// X-Forwarded-* headers of trusted proxies
+		{"192.168.1.1:1234", http.Header{
+			"X-Forwarded-Proto":  {"HTTPS"},
+			"X-Forwarded-Host":   {"api.example.com"},
+			"X-Forwarded-Prefix": {"/orders/"},
+		}, "https://api.example.com/orders"},
+		{"[::1]:1234", http.Header{"X-Forwarded-Host": {"api.example.com"}}, "http://api.example.com"},
+		// Values are taken from the outermost trusted proxy
+		{"10.0.0.1:1234", http.Header{
+			"X-Forwarded-For":   {"1.2.3.4, 10.0.0.2"},
+			"X-Forwarded-Proto": {"https, http"},
+			"X-Forwarded-Host":  {"api.example.com", "internal"},
+		}, "https://api.example.com"},
+		{"10.0.0.1:1234", http.Header{
+			"X-Forwarded-For":  {"1.2.3.4, 5.6.7.8"},
+			"X-Forwarded-Host": {"evil.com, api.example.com"},
+		}, "http://api.example.com"},
+		{"10.0.0.1:1234", http.Header{
+			"X-Forwarded-For":    {"1.2.3.4"},
+			"X-Forwarded-Prefix": {"/evil", "/api"},
+		}, "http://example.com/api"},
+		{"10.0.0.1:1234", http.Header{
+			"Forwarded":          {`for=1.2.3.4;host=api.example.com`},
+			"X-Forwarded-Prefix": {"/evil, /api/"},
+		}, "http://api.example.com/api"},
+		// Forwarded header takes precedence
+		{"10.0.0.1:1234", http.Header{
+			"Forwarded":        {`for=1.2.3.4;proto=https;host="api.example.com", for=10.0.0.2;host=internal`},
+			"X-Forwarded-Host": {"other.example.com"},
+		}, "https://api.example.com"},
+		{"10.0.0.1:1234", http.Header{
+			"Forwarded": {`for=1.2.3.4;host=evil.com, for=5.6.7.8;proto=https;host=api.example.com`},
+		}, "https://api.example.com"},
+		{"10.0.0.1:1234", http.Header{
+			"Forwarded": {`for=1.2.3.4;host=api.example.com`, `for="[::1]";proto=https`},
+		}, "https://api.example.com"},
+	}
+	for _, testCase := range testCases {
+		r := httptest.NewRequest("GET", "/v1/hello/world/1", nil)
+		r.RemoteAddr = testCase.remoteAddr
+		r.Header = testCase.header
+		assert.Equal(t, testCase.expected, resolver.BaseURL(r).String(), testCase.header)
+	}
+
+	_, err = NewBaseURLResolver("bogus")
+	assert.EqualError(t, err, "Invalid trusted proxy \"bogus\"")
+	_, err = NewBaseURLResolver("10.0.0.0/33")
+	assert.Error(t, err)
+}
+
+func TestResolveHrefs(t *testing.T) {
+	base := &url.URL{Scheme: "https", Host: "api.example.com", Path: "/orders"}
+
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	helloWorld.SetLink("search", "/v1/hello/world{?q}", "", Templated())
+	helloWorld.SetLink("relative", "world/2", "")
+	helloWorld.SetLink("external", "http://example.com/foo", "")
+	helloWorld.SetLink("network", "//example.com/foo", "")
+	helloWorld.SetLink("next", "?page=2", "")
+	helloWorld.SetLink("section", "#items", "")
+	helloWorld.SetLink("current", "", "")
+	helloWorld.SetLink("expression", "{+path}/items", "", Templated())
+	foobar := &Foobar{ID: 1}
+	foobar.SetLink("self", "/v1/foo/bar/1", "")
+	qux := Qux{ID: 1}
+	qux.SetLink("self", "/v1/qux/1", "")
+	helloWorld.SetEmbedded("foobar", foobar)
+	helloWorld.SetEmbedded("quxes", []Qux{qux})
+	helloWorld.SetEmbedded("qux", qux)
+
+	helloWorld.ResolveHrefs(base)
+	for rel, expected := range map[string]string{
+		"self":       "https://api.example.com/orders/v1/hello/world/1",
+		"search":     "https://api.example.com/orders/v1/hello/world{?q}",
+		"relative":   "https://api.example.com/world/2",
+		"external":   "http://example.com/foo",
+		"network":    "//example.com/foo",
+		"next":       "https://api.example.com/orders?page=2",
+		"section":    "https://api.example.com/orders#items",
+		"current":    "https://api.example.com/orders",
+		"expression": "{+path}/items",
+	} {
+		link, err := helloWorld.GetLink(rel)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, link.Href, rel)
+	}
+	link, _ := foobar.GetLink("self")
+	assert.Equal(t, "https://api.example.com/orders/v1/foo/bar/1", link.Href)
+	link, _ = helloWorld.Embedded["quxes"].([]Qux)[0].GetLink("self")
+	assert.Equal(t, "https://api.example.com/orders/v1/qux/1", link.Href)
+	embedded := helloWorld.Embedded["qux"].(Qux)
+	link, _ = embedded.GetLink("self")
+	assert.Equal(t, "https://api.example.com/orders/v1/qux/1", link.Href)
+
+	// Test the base query is kept for fragments
+	base.RawQuery = "lang=en"
+	assert.Equal(t, "https://api.example.com/orders?lang=en#items", resolveBaseHref(base, "#items"))
+	assert.Equal(t, "https://api.example.com/orders?page=2", resolveBaseHref(base, "?page=2"))
+
+	// Test dot segments are removed
+	base.RawQuery = ""
+	assert.Equal(t, "https://api.example.com/x", resolveBaseHref(base, "../x"))
+	assert.Equal(t, "https://api.example.com/x?page=2", resolveBaseHref(base, "./x?page=2"))
+	assert.Equal(t, "https://api.example.com/orders/", resolveBaseHref(base, "/v1/.."))
+	assert.Equal(t, "https://api.example.com/orders/v1/{id}/../x", resolveBaseHref(base, "/v1/./{id}/../x"))
+	base.Path = "/orders/v1/items/1"
+	assert.Equal(t, "https://api.example.com/orders/v1/items/2", resolveBaseHref(base, "../items/2"))
+	assert.Equal(t, "https://api.example.com/orders/v1/", resolveBaseHref(base, ".."))
+
+	// Test resources embedding a nil *Hal are skipped
+	resource := new(Hal)
+	resource.SetEmbedded("pointer", &pointerEmbedding{Name: "Bob"})
+	resource.SetEmbedded("value", pointerEmbedding{Name: "Bob"})
+	assert.NotPanics(t, func() { resource.ResolveHrefs(base) })
+}
+
+func TestBaseURLMiddleware(t *testing.T) {
+	resolver, err := NewBaseURLResolver("10.0.0.1")
+	assert.NoError(t, err)
+
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base, ok := BaseURLFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "https://api.example.com", base.String())
+		Negotiate(w, r, http.StatusOK, helloWorld)
+	}))
+
+	r := httptest.NewRequest("GET", "/v1/hello/world/1", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Host", "api.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(
+		t,
+		`{"_links":{"self":{"href":"https://api.example.com/v1/hello/world/1"}},"id":1,"name":"Hello World"}`+"\n",
+		w.Body.String(),
+	)
+
+	r.Header.Set("Accept", XMLContentType)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Contains(t, w.Body.String(), `<resource href="https://api.example.com/v1/hello/world/1">`)
+
+	// The resource itself is left untouched
+	link, _ := helloWorld.GetLink("self")
+	assert.Equal(t, "/v1/hello/world/1", link.Href)
+	_, ok := BaseURLFromContext(r.Context())
+	assert.False(t, ok)
+}
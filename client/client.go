@@ -0,0 +1,219 @@
+// Package client provides a HAL aware HTTP client which fetches resources
+// and follows their links by relation name
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/AreaHQ/jsonhal/v2"
+)
+
+// DefaultAccept is the Accept header sent with every request
+// unless it is overridden
+const DefaultAccept = "application/hal+json, application/json;q=0.9"
+
+// Document is a HAL resource fetched from URL
+type Document struct {
+	*jsonhal.Resource
+	URL        *url.URL
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Decode decodes the document into v, see jsonhal.Unmarshal
+func (d *Document) Decode(v interface{}, types jsonhal.EmbeddedTypes) error {
+	return jsonhal.Unmarshal(d.Body, v, types)
+}
+
+// HeaderLinks parses RFC 8288 Link headers of the document, relative
+// hrefs are resolved against the document URL
+func (d *Document) HeaderLinks() (map[string]jsonhal.LinkSet, error) {
+	return ParseLinkHeader(d.URL, d.Header)
+}
+
+// ParseLinkHeader parses RFC 8288 Link headers of a response, relative
+// hrefs are resolved against base unless it is nil
+func ParseLinkHeader(base *url.URL, header http.Header) (map[string]jsonhal.LinkSet, error) {
+	links, err := jsonhal.ParseLinkHeader(header.Values("Link")...)
+	if err != nil || base == nil {
+		return links, err
+	}
+	for _, set := range links {
+		for _, link := range set.Links {
+			target, err := url.Parse(link.Href)
+			if err != nil {
+				return nil, err
+			}
+			link.Href = base.ResolveReference(target).String()
+		}
+	}
+	return links, nil
+}
+
+// Step is a single link relation to follow with optional
+// parameters for templated links
+type Step struct {
+	Rel    string
+	Params map[string]interface{}
+}
+
+// Client fetches HAL resources and follows their links
+type Client struct {
+	httpClient *http.Client
+	header     http.Header
+}
+
+// Option configures a client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used to send requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithHeader sets a header sent with every request
+func WithHeader(key, value string) Option {
+	return func(c *Client) { c.header.Set(key, value) }
+}
+
+// RequestOption customises a single request
+type RequestOption func(*http.Request)
+
+// Header sets a header of a single request
+func Header(key, value string) RequestOption {
+	return func(r *http.Request) { r.Header.Set(key, value) }
+}
+
+// New returns a new client, http.DefaultClient is used
+// to send requests unless set otherwise
+func New(options ...Option) *Client {
+	c := &Client{httpClient: http.DefaultClient, header: make(http.Header)}
+	c.header.Set("Accept", DefaultAccept)
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// Get fetches and decodes a HAL resource
+func (c *Client) Get(ctx context.Context, rawurl string, options ...RequestOption) (*Document, error) {
+	target, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return c.get(ctx, target, options)
+}
+
+// Follow fetches the resource linked from a document by link relation rel,
+// templated links are expanded using params. Relative hrefs are resolved
+// against the document URL. Errors are reported as *LinkError
+func (c *Client) Follow(ctx context.Context, from *Document, rel string, params map[string]interface{}, options ...RequestOption) (*Document, error) {
+	return c.FollowChain(ctx, from, []Step{{Rel: rel, Params: params}}, options...)
+}
+
+// FollowChain follows link relations one after another starting from
+// a document and returns the last fetched document. Errors are reported
+// as *LinkError so it is clear which relation in the chain failed
+func (c *Client) FollowChain(ctx context.Context, from *Document, steps []Step, options ...RequestOption) (*Document, error) {
+	chain := make([]string, 0, len(steps))
+	for _, step := range steps {
+		chain = append(chain, step.Rel)
+		target, err := ResolveLink(from, step.Rel, step.Params)
+		if err == nil {
+			from, err = c.get(ctx, target, options)
+		}
+		if err != nil {
+			return nil, &LinkError{Chain: chain, Rel: step.Rel, Err: err}
+		}
+	}
+	return from, nil
+}
+
+// ResolveLink returns the absolute URL of a document link by relation rel,
+// templated links are expanded using params
+func ResolveLink(from *Document, rel string, params map[string]interface{}) (*url.URL, error) {
+	link, err := from.GetLink(rel)
+	if err != nil {
+		return nil, err
+	}
+	return resolveHref(from, link, params)
+}
+
+func (c *Client) get(ctx context.Context, target *url.URL, options []RequestOption) (*Document, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	for _, option := range options {
+		option(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &StatusError{URL: target.String(), StatusCode: resp.StatusCode, Body: body}
+	}
+
+	doc := &Document{
+		Resource:   jsonhal.NewResource(),
+		URL:        target,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, doc.Resource); err != nil {
+			return nil, &jsonhal.InvalidDocumentError{Err: fmt.Errorf("%s: %w", target, err)}
+		}
+	}
+	return doc, nil
+}
+
+// StatusError is returned when a server responds with a non 2xx status code
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// LinkError reports which link relation in a chain could not be followed
+type LinkError struct {
+	Chain []string // relations followed up to and including the failed one
+	Rel   string   // relation which failed
+	Err   error
+}
+
+func (e *LinkError) Error() string {
+	rels := make([]string, len(e.Chain))
+	for i, rel := range e.Chain {
+		rels[i] = fmt.Sprintf("\"%s\"", rel)
+	}
+	return fmt.Sprintf("Following %s failed: %s", strings.Join(rels, " -> "), e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *LinkError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AreaHQ/jsonhal/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// Order is a simple test struct
+type Order struct {
+	jsonhal.Hal
+	ID     uint   `json:"id"`
+	Status string `json:"status"`
+}
+
+var testDocuments = map[string]string{
+	"/v1": `{
+		"_links": {
+			"self": {"href": "/v1"},
+			"orders": {"href": "/v1/orders{?status}", "templated": true},
+			"broken": {"href": "/v1/broken"},
+			"invalid": {"href": "/v1/invalid"}
+		}
+	}`,
+	"/v1/orders?status=shipped": `{
+		"_links": {
+			"self": {"href": "/v1/orders?status=shipped"},
+			"first": {"href": "orders/1"}
+		},
+		"count": 1
+	}`,
+	"/v1/orders/1": `{
+		"_links": {
+			"self": {"href": "/v1/orders/1"},
+			"customer": {"href": "http://customers.example.com/v1/customers/1"}
+		},
+		"id": 1,
+		"status": "shipped"
+	}`,
+	"/v1/invalid": `{"_links": []}`,
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		document, ok := testDocuments[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Header().Set("X-Accept", r.Header.Get("Accept"))
+		w.Write([]byte(document))
+	}))
+}
+
+func TestGetAndFollow(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	ctx := context.Background()
+	c := New(WithHTTPClient(server.Client()), WithHeader("X-Api-Key", "secret"))
+
+	root, err := c.Get(ctx, server.URL+"/v1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, root.StatusCode)
+	assert.Equal(t, DefaultAccept, root.Header.Get("X-Accept"))
+
+	// Test following a templated link with parameters
+	orders, err := c.Follow(ctx, root, "orders", map[string]interface{}{"status": "shipped"})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, server.URL+"/v1/orders?status=shipped", orders.URL.String())
+	count, _ := orders.GetProperty("count")
+	assert.Equal(t, json.Number("1"), count)
+
+	// Test relative hrefs are resolved against the document URL
+	order, err := c.Follow(ctx, orders, "first", nil, Header("Accept", "application/hal+json"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "application/hal+json", order.Header.Get("X-Accept"))
+
+	decoded := new(Order)
+	assert.NoError(t, order.Decode(decoded, nil))
+	assert.Equal(t, "shipped", decoded.Status)
+	link, err := decoded.GetLink("self")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/orders/1", link.Href)
+
+	// Test following a chain
+	order, err = c.FollowChain(ctx, root, []Step{
+		{Rel: "orders", Params: map[string]interface{}{"status": "shipped"}},
+		{Rel: "first"},
+		{Rel: "self"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/v1/orders/1", order.URL.String())
+}
+
+func TestFollowErrors(t *testing.T) {
+	server := newTestServer(t)
+	defer server.Close()
+
+	var (
+		linkErr   *LinkError
+		statusErr *StatusError
+		err       error
+	)
+
+	ctx := context.Background()
+	c := New(WithHTTPClient(server.Client()), WithHeader("X-Api-Key", "secret"))
+	root, err := c.Get(ctx, server.URL+"/v1")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Test a missing link in a chain
+	_, err = c.FollowChain(ctx, root, []Step{
+		{Rel: "orders", Params: map[string]interface{}{"status": "shipped"}},
+		{Rel: "bogus"},
+		{Rel: "self"},
+	})
+	if assert.True(t, errors.As(err, &linkErr)) {
+		assert.Equal(t, "bogus", linkErr.Rel)
+		assert.Equal(t, []string{"orders", "bogus"}, linkErr.Chain)
+	}
+	assert.True(t, errors.Is(err, jsonhal.ErrLinkNotFound))
+	assert.EqualError(t, err, "Following \"orders\" -> \"bogus\" failed: Link \"bogus\" not found")
+
+	// Test an error response
+	_, err = c.Follow(ctx, root, "broken", nil)
+	if assert.True(t, errors.As(err, &statusErr)) {
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	}
+	assert.True(t, errors.As(err, &linkErr))
+
+	// Test per request headers override client headers
+	_, err = c.Follow(ctx, root, "self", nil, Header("X-Api-Key", "bogus"))
+	if assert.True(t, errors.As(err, &statusErr)) {
+		assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+	}
+
+	// Test an invalid document
+	_, err = c.Follow(ctx, root, "invalid", nil)
+	assert.True(t, errors.Is(err, jsonhal.ErrInvalidDocument))
+
+	// Test a cancelled context
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = c.Follow(cancelled, root, "self", nil)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
+
+func TestHeaderLinks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order := &Order{ID: 1, Status: "shipped"}
+		order.SetLink("self", "/v1/orders/1", "")
+		order.AddLink("item", "items/1", "Item 1")
+		order.AddLink("item", "http://example.com/items/2", "Item 2")
+		jsonhal.WriteResource(w, r, http.StatusOK, order, jsonhal.WithLinkHeader())
+	}))
+	defer server.Close()
+
+	document, err := New().Get(context.Background(), server.URL+"/v1/orders/1")
+	assert.NoError(t, err)
+	links, err := document.HeaderLinks()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]jsonhal.LinkSet{
+		"self": {Links: []*jsonhal.Link{{Href: server.URL + "/v1/orders/1"}}},
+		"item": {Links: []*jsonhal.Link{
+			{Href: server.URL + "/v1/orders/items/1", Title: "Item 1"},
+			{Href: "http://example.com/items/2", Title: "Item 2"},
+		}},
+	}, links)
+
+	links, err = ParseLinkHeader(nil, http.Header{"Link": {`</v1>; rel="self"`}})
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1", links["self"].Links[0].Href)
+
+	_, err = ParseLinkHeader(nil, http.Header{"Link": {`</v1>; rel="self`}})
+	assert.Error(t, err)
+}
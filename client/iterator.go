@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/AreaHQ/jsonhal/v2"
+)
+
+// ErrMaxPages is returned by an iterator which reached its maximum
+// number of pages while there still was a next page
+var ErrMaxPages = errors.New("Maximum number of pages reached")
+
+// Iterator yields resources embedded in pages of a collection, following
+// "next" links until there are none left. It works the same way for offset,
+// numbered and cursor pages, for example:
+//
+//	it := c.Iterate(ctx, "https://api.example.com/v1/orders", "orders")
+//	for it.Next() {
+//		order := it.Item()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iterator struct {
+	client   *Client
+	ctx      context.Context
+	rel      string
+	options  []RequestOption
+	maxPages int
+	pageRel  string
+
+	start *Document
+	next  *url.URL
+	page  *Document
+	pages int
+	items []*jsonhal.Resource
+	item  *jsonhal.Resource
+	err   error
+}
+
+// Iterate returns an iterator over resources embedded under rel
+// in pages of a collection starting at rawurl
+func (c *Client) Iterate(ctx context.Context, rawurl, rel string, options ...RequestOption) *Iterator {
+	it := &Iterator{client: c, ctx: ctx, rel: rel, options: options, pageRel: "next"}
+	it.next, it.err = url.Parse(rawurl)
+	return it
+}
+
+// IterateFrom returns an iterator over resources embedded under rel
+// in pages of a collection starting at an already fetched page
+func (c *Client) IterateFrom(ctx context.Context, page *Document, rel string, options ...RequestOption) *Iterator {
+	return &Iterator{client: c, ctx: ctx, rel: rel, options: options, pageRel: "next", start: page, page: page}
+}
+
+// MaxPages sets the maximum number of pages to fetch, Next stops with
+// ErrMaxPages when there are more. Zero means there is no limit
+func (it *Iterator) MaxPages(maxPages int) *Iterator {
+	it.maxPages = maxPages
+	return it
+}
+
+// PageRel sets the link relation followed to get the next page, "next"
+// by default. Use "previous" to iterate over a collection backwards
+func (it *Iterator) PageRel(rel string) *Iterator {
+	it.pageRel = rel
+	return it
+}
+
+// Next advances to the next item, fetching the next page when needed.
+// It returns false when there are no more items or an error occurred
+func (it *Iterator) Next() bool {
+	for it.err == nil {
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			break
+		}
+		if it.start != nil {
+			it.setPage(it.start)
+			it.start = nil
+			continue
+		}
+		if len(it.items) > 0 {
+			it.item, it.items = it.items[0], it.items[1:]
+			return true
+		}
+		if it.next == nil {
+			break
+		}
+		if it.maxPages > 0 && it.pages >= it.maxPages {
+			it.err = ErrMaxPages
+			break
+		}
+		page, err := it.client.get(it.ctx, it.next, it.options)
+		if err != nil {
+			it.err = err
+			break
+		}
+		it.setPage(page)
+	}
+	it.item = nil
+	return false
+}
+
+// setPage makes page the current page
+func (it *Iterator) setPage(page *Document) {
+	it.page = page
+	it.pages++
+	it.next = nil
+	it.items, it.err = pageItems(page, it.rel)
+	if it.err != nil {
+		return
+	}
+	if next, err := page.GetLink(it.pageRel); err == nil {
+		it.next, it.err = resolveHref(page, next, nil)
+	}
+}
+
+// Item returns the current item
+func (it *Iterator) Item() *jsonhal.Resource {
+	return it.item
+}
+
+// Decode decodes the current item into v, see jsonhal.Unmarshal
+func (it *Iterator) Decode(v interface{}, types jsonhal.EmbeddedTypes) error {
+	if it.item == nil {
+		return fmt.Errorf("No current item")
+	}
+	data, err := json.Marshal(it.item)
+	if err != nil {
+		return err
+	}
+	return jsonhal.Unmarshal(data, v, types)
+}
+
+// Page returns the current page, use it to read collection metadata
+func (it *Iterator) Page() *Document {
+	return it.page
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// pageItems returns resources embedded in a page under rel,
+// a page without them has no items
+func pageItems(page *Document, rel string) ([]*jsonhal.Resource, error) {
+	items, err := jsonhal.GetEmbeddedSlice[*jsonhal.Resource](page, rel)
+	if errors.Is(err, jsonhal.ErrEmbeddedNotFound) {
+		return nil, nil
+	}
+	return items, err
+}
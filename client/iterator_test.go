@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/AreaHQ/jsonhal/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// newPaginatedServer returns a server with a collection of orders
+// split into pages of two, the same way as in the README example
+func newPaginatedServer(total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		links := fmt.Sprintf(`"self": {"href": "/v1/orders?offset=%d&limit=2"}`, offset)
+		if offset+2 < total {
+			links += fmt.Sprintf(`, "next": {"href": "/v1/orders?offset=%d&limit=2"}`, offset+2)
+		}
+		orders := ""
+		for id := offset + 1; id <= offset+2 && id <= total; id++ {
+			if orders != "" {
+				orders += ","
+			}
+			orders += fmt.Sprintf(`{"_links": {"self": {"href": "/v1/orders/%d"}}, "id": %d}`, id, id)
+		}
+		w.Header().Set("Content-Type", "application/hal+json")
+		fmt.Fprintf(w, `{"_links": {%s}, "_embedded": {"orders": [%s]}, "total": %d}`, links, orders, total)
+	}))
+}
+
+func TestIterator(t *testing.T) {
+	server := newPaginatedServer(5)
+	defer server.Close()
+
+	ctx := context.Background()
+	c := New(WithHTTPClient(server.Client()))
+
+	// Test iterating over all pages
+	ids := make([]uint, 0)
+	selfs := make([]string, 0)
+	it := c.Iterate(ctx, server.URL+"/v1/orders", "orders")
+	for it.Next() {
+		order := new(Order)
+		assert.NoError(t, it.Decode(order, nil))
+		ids = append(ids, order.ID)
+		link, err := it.Page().GetLink("self")
+		assert.NoError(t, err)
+		selfs = append(selfs, link.Href)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []uint{1, 2, 3, 4, 5}, ids)
+	assert.Equal(t, "/v1/orders?offset=4&limit=2", selfs[4])
+	assert.Nil(t, it.Item())
+	assert.False(t, it.Next())
+
+	// Test starting from an already fetched page
+	page, err := c.Get(ctx, server.URL+"/v1/orders?offset=2&limit=2")
+	assert.NoError(t, err)
+	it = c.IterateFrom(ctx, page, "orders")
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 3, count)
+
+	// Test a page without items
+	page, err = c.Get(ctx, server.URL+"/v1/orders?offset=6&limit=2")
+	assert.NoError(t, err)
+	it = c.IterateFrom(ctx, page, "bogus")
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
+
+func TestIteratorSafeguards(t *testing.T) {
+	server := newPaginatedServer(10)
+	defer server.Close()
+
+	c := New(WithHTTPClient(server.Client()))
+
+	// Test the max pages safeguard
+	it := c.Iterate(context.Background(), server.URL+"/v1/orders", "orders").MaxPages(2)
+	count := 0
+	for it.Next() {
+		count++
+	}
+	assert.Equal(t, 4, count)
+	assert.True(t, errors.Is(it.Err(), ErrMaxPages))
+
+	// Test context cancellation
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	it = c.Iterate(ctx, server.URL+"/v1/orders", "orders")
+	count = 0
+	for it.Next() {
+		count++
+		if count == 3 {
+			cancel()
+		}
+	}
+	assert.Equal(t, 3, count)
+	assert.True(t, errors.Is(it.Err(), context.Canceled))
+
+	// Test decoding without a current item
+	assert.EqualError(t, it.Decode(new(Order), nil), "No current item")
+
+	// Test a request error
+	it = c.Iterate(context.Background(), "http://%zz", "orders")
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
+
+// newCursorServer returns a server with a collection of orders paginated
+// by signed cursors built with jsonhal collection tooling
+func newCursorServer(total int) *httptest.Server {
+	codec := jsonhal.CursorCodec{Secret: []byte("secret")}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("cursor")
+		first, last := 1, 2
+		if token != "" {
+			cursor, err := codec.Decode(token)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var key int
+			cursor.DecodeKey(&key)
+			first, last = key+1, key+2
+			if cursor.Backward {
+				first, last = key-2, key-1
+			}
+		}
+		if first < 1 {
+			first = 1
+		}
+		if last > total {
+			last = total
+		}
+		orders := make([]*Order, 0)
+		for id := first; id <= last; id++ {
+			orders = append(orders, &Order{ID: uint(id)})
+		}
+		page, _ := codec.NewPage(token, 2, first, last, first > 1, last < total)
+		collection, _ := jsonhal.NewCollection("/v1/orders", page, total, "orders", orders)
+		w.Header().Set("Content-Type", "application/hal+json")
+		json.NewEncoder(w).Encode(collection)
+	}))
+}
+
+func TestCursorIterator(t *testing.T) {
+	server := newCursorServer(5)
+	defer server.Close()
+
+	ctx := context.Background()
+	c := New(WithHTTPClient(server.Client()))
+
+	iterate := func(it *Iterator) []uint {
+		ids := make([]uint, 0)
+		for it.Next() {
+			order := new(Order)
+			assert.NoError(t, it.Decode(order, nil))
+			ids = append(ids, order.ID)
+		}
+		assert.NoError(t, it.Err())
+		return ids
+	}
+
+	// Test iterating forwards
+	it := c.Iterate(ctx, server.URL+"/v1/orders", "orders")
+	assert.Equal(t, []uint{1, 2, 3, 4, 5}, iterate(it))
+
+	// Test iterating backwards from the last page
+	last := it.Page()
+	_, err := last.GetLink("next")
+	assert.True(t, errors.Is(err, jsonhal.ErrLinkNotFound))
+	it = c.IterateFrom(ctx, last, "orders").PageRel("previous")
+	assert.Equal(t, []uint{5, 3, 4, 1, 2}, iterate(it))
+}
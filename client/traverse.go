@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/AreaHQ/jsonhal/v2"
+)
+
+// Traversal is a fluent builder following a chain of link relations
+// from a root resource, for example:
+//
+//	order, err := c.Traverse("https://api.example.com/v1").
+//		Follow("ea:orders").
+//		FollowTemplate("ea:find", map[string]interface{}{"id": 1}).
+//		Follow("self").
+//		Get(ctx)
+//
+// A relation can select one of multiple links or embedded resources
+// by index, e.g. "ea:order[1]", the first one is used otherwise. When
+// the next resource is already embedded in the current one it is used
+// instead of issuing a request
+type Traversal struct {
+	client  *Client
+	root    string
+	steps   []Step
+	options []RequestOption
+}
+
+// Traverse starts a new traversal at rootURL
+func (c *Client) Traverse(rootURL string) *Traversal {
+	return &Traversal{client: c, root: rootURL}
+}
+
+// Follow adds link relations to follow
+func (t *Traversal) Follow(rels ...string) *Traversal {
+	for _, rel := range rels {
+		t.steps = append(t.steps, Step{Rel: rel})
+	}
+	return t
+}
+
+// FollowTemplate adds a templated link relation to follow,
+// the link is expanded using params
+func (t *Traversal) FollowTemplate(rel string, params map[string]interface{}) *Traversal {
+	t.steps = append(t.steps, Step{Rel: rel, Params: params})
+	return t
+}
+
+// WithOptions sets options applied to every request of the traversal
+func (t *Traversal) WithOptions(options ...RequestOption) *Traversal {
+	t.options = append(t.options, options...)
+	return t
+}
+
+// Get runs the traversal and returns the final resource
+func (t *Traversal) Get(ctx context.Context) (*Document, error) {
+	doc, err := t.client.Get(ctx, t.root, t.options...)
+	if err != nil {
+		return nil, err
+	}
+	chain := make([]string, 0, len(t.steps))
+	for _, step := range t.steps {
+		chain = append(chain, step.Rel)
+		if doc, err = t.client.step(ctx, doc, step, t.options); err != nil {
+			return nil, &LinkError{Chain: chain, Rel: step.Rel, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+// Decode runs the traversal and decodes the final resource into v,
+// see jsonhal.Unmarshal
+func (t *Traversal) Decode(ctx context.Context, v interface{}, types jsonhal.EmbeddedTypes) error {
+	doc, err := t.Get(ctx)
+	if err != nil {
+		return err
+	}
+	return doc.Decode(v, types)
+}
+
+// step moves from a document to the resource embedded or linked
+// under the step relation
+func (c *Client) step(ctx context.Context, from *Document, step Step, options []RequestOption) (*Document, error) {
+	rel, index, err := parseRel(step.Rel)
+	if err != nil {
+		return nil, err
+	}
+	if embedded, err := from.GetEmbedded(rel); err == nil {
+		return embeddedDocument(from, rel, embedded, index)
+	}
+
+	links, err := from.GetLinks(rel)
+	if err != nil {
+		return nil, err
+	}
+	if index >= len(links) {
+		return nil, fmt.Errorf("Link \"%s\" has no index %d: %w", rel, index, jsonhal.ErrLinkNotFound)
+	}
+	target, err := resolveHref(from, links[index], step.Params)
+	if err != nil {
+		return nil, err
+	}
+	return c.get(ctx, target, options)
+}
+
+// embeddedDocument returns an embedded resource as a document, its URL
+// is taken from the resource self link if there is one
+func embeddedDocument(from *Document, rel string, embedded jsonhal.Embedded, index int) (*Document, error) {
+	var resource *jsonhal.Resource
+	switch typed := embedded.(type) {
+	case *jsonhal.Resource:
+		if index > 0 {
+			return nil, fmt.Errorf("Embedded \"%s\" has no index %d: %w", rel, index, jsonhal.ErrEmbeddedNotFound)
+		}
+		resource = typed
+	case []*jsonhal.Resource:
+		if index >= len(typed) {
+			return nil, fmt.Errorf("Embedded \"%s\" has no index %d: %w", rel, index, jsonhal.ErrEmbeddedNotFound)
+		}
+		resource = typed[index]
+	}
+	if resource == nil {
+		return nil, fmt.Errorf("Embedded \"%s\" is not a resource: %w", rel, jsonhal.ErrTypeMismatch)
+	}
+	body, err := json.Marshal(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		Resource:   resource,
+		URL:        from.URL,
+		StatusCode: from.StatusCode,
+		Header:     from.Header,
+		Body:       body,
+	}
+	if self, err := resource.GetLink("self"); err == nil {
+		if doc.URL, err = resolveHref(from, self, nil); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// parseRel splits a relation with an optional index, e.g. "ea:order[1]"
+func parseRel(rel string) (string, int, error) {
+	if !strings.HasSuffix(rel, "]") {
+		return rel, 0, nil
+	}
+	i := strings.LastIndex(rel, "[")
+	if i == -1 {
+		return rel, 0, nil
+	}
+	index, err := strconv.Atoi(rel[i+1 : len(rel)-1])
+	if err != nil || index < 0 {
+		return "", 0, fmt.Errorf("Invalid index in \"%s\"", rel)
+	}
+	return rel[:i], index, nil
+}
+
+// resolveHref returns the absolute URL of a link,
+// templated links are expanded using params
+func resolveHref(from *Document, link *jsonhal.Link, params map[string]interface{}) (*url.URL, error) {
+	href, err := link.Expand(params)
+	if err != nil {
+		return nil, err
+	}
+	target, err := url.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+	if from.URL != nil {
+		target = from.URL.ResolveReference(target)
+	}
+	return target, nil
+}
@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AreaHQ/jsonhal/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var traversalDocuments = map[string]string{
+	"/": `{
+		"_links": {
+			"self": {"href": "/"},
+			"ea:orders": {"href": "/orders"},
+			"ea:find": {"href": "/orders/{id}", "templated": true}
+		}
+	}`,
+	"/orders": `{
+		"_links": {
+			"self": {"href": "/orders"}
+		},
+		"_embedded": {
+			"ea:order": [
+				{
+					"_links": {
+						"self": {"href": "/orders/123"},
+						"ea:customer": {"href": "/customers/7809"}
+					},
+					"id": 123,
+					"status": "shipped"
+				},
+				{
+					"_links": {
+						"self": {"href": "/orders/124"},
+						"ea:customer": {"href": "/customers/12369"}
+					},
+					"id": 124,
+					"status": "processing"
+				}
+			]
+		}
+	}`,
+	"/orders/124": `{
+		"_links": {
+			"self": {"href": "/orders/124"},
+			"ea:customer": {"href": "/customers/12369"}
+		},
+		"id": 124,
+		"status": "processing"
+	}`,
+	"/customers/12369": `{
+		"_links": {
+			"self": {"href": "/customers/12369"}
+		},
+		"name": "Jane"
+	}`,
+}
+
+func TestTraversal(t *testing.T) {
+	requests := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		document, ok := traversalDocuments[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/hal+json")
+		w.Write([]byte(document))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	c := New(WithHTTPClient(server.Client()))
+
+	// Test embedded resources are used instead of issuing requests
+	doc, err := c.Traverse(server.URL).Follow("ea:orders", "ea:order[1]").Get(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, server.URL+"/orders/124", doc.URL.String())
+		status, _ := doc.GetProperty("status")
+		assert.Equal(t, "processing", status)
+	}
+	assert.Equal(t, []string{"/", "/orders"}, requests)
+
+	// Test decoding the final resource
+	order := new(Order)
+	err = c.Traverse(server.URL).Follow("ea:orders", "ea:order").Decode(ctx, order, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(123), order.ID)
+
+	// Test mixing templated links, embedded resources and requests
+	requests = requests[:0]
+	doc, err = c.Traverse(server.URL).
+		FollowTemplate("ea:find", map[string]interface{}{"id": 124}).
+		Follow("ea:customer", "self").
+		WithOptions(Header("X-Trace", "1")).
+		Get(ctx)
+	if assert.NoError(t, err) {
+		name, _ := doc.GetProperty("name")
+		assert.Equal(t, "Jane", name)
+	}
+	assert.Equal(t, []string{"/", "/orders/124", "/customers/12369", "/customers/12369"}, requests)
+
+	// Test errors report the failed relation
+	var linkErr *LinkError
+	_, err = c.Traverse(server.URL).Follow("ea:orders", "ea:order[2]", "ea:customer").Get(ctx)
+	if assert.True(t, errors.As(err, &linkErr)) {
+		assert.Equal(t, []string{"ea:orders", "ea:order[2]"}, linkErr.Chain)
+	}
+	assert.True(t, errors.Is(err, jsonhal.ErrEmbeddedNotFound))
+	assert.EqualError(t, err, "Following \"ea:orders\" -> \"ea:order[2]\" failed: Embedded \"ea:order\" has no index 2: embedded not found")
+
+	_, err = c.Traverse(server.URL).Follow("ea:orders", "ea:order", "ea:bogus").Get(ctx)
+	assert.True(t, errors.Is(err, jsonhal.ErrLinkNotFound))
+
+	_, err = c.Traverse(server.URL).Follow("self[x]").Get(ctx)
+	assert.EqualError(t, err, "Following \"self[x]\" failed: Invalid index in \"self[x]\"")
+
+	_, err = c.Traverse(server.URL).Follow("self[1]").Get(ctx)
+	assert.True(t, errors.Is(err, jsonhal.ErrLinkNotFound))
+	assert.EqualError(t, err, "Following \"self[1]\" failed: Link \"self\" has no index 1: link not found")
+
+	_, err = embeddedDocument(new(Document), "ea:order", []string{"bogus"}, 0)
+	assert.True(t, errors.Is(err, jsonhal.ErrTypeMismatch))
+	assert.EqualError(t, err, "Embedded \"ea:order\" is not a resource: type mismatch")
+}
@@ -0,0 +1,233 @@
+package jsonhal
+
+import (
+	"reflect"
+)
+
+// WithLink returns a copy of h with a link set, see SetLink. Unlike SetLink
+// it leaves h untouched, maps which are not modified are shared with h so
+// values built with With methods should be treated as immutable
+func (h Hal) WithLink(name, href, title string, options ...LinkOption) Hal {
+	h.Links = copyLinks(h.Links, 1)
+	h.arrays = copyMap(h.arrays, 0)
+	h.SetLink(name, href, title, options...)
+	return h
+}
+
+// WithAddedLink returns a copy of h with a link appended, see AddLink
+func (h Hal) WithAddedLink(name, href, title string, options ...LinkOption) Hal {
+	h.Links = copyLinks(h.Links, 1)
+	h.arrays = copyMap(h.arrays, 0)
+	h.AddLink(name, href, title, options...)
+	return h
+}
+
+// WithoutLink returns a copy of h without links named name
+func (h Hal) WithoutLink(name string) Hal {
+	if _, ok := h.Links[name]; !ok {
+		return h
+	}
+	h.Links = copyLinks(h.Links, 0)
+	h.arrays = copyMap(h.arrays, 0)
+	delete(h.Links, name)
+	return h
+}
+
+// WithLinkArray returns a copy of h with links named name set to always
+// marshal into an array or not, see SetLinkArray
+func (h Hal) WithLinkArray(name string, array bool) Hal {
+	h.Links = copyLinks(h.Links, 0)
+	h.arrays = copyMap(h.arrays, 1)
+	h.SetLinkArray(name, array)
+	return h
+}
+
+// WithEmbedded returns a copy of h with an embedded resource set,
+// see SetEmbedded. The embedded resource itself is not copied
+func (h Hal) WithEmbedded(name string, embedded Embedded) Hal {
+	h.Embedded = copyMap(h.Embedded, 1)
+	h.SetEmbedded(name, embedded)
+	return h
+}
+
+// WithoutEmbedded returns a copy of h without an embedded resource named name
+func (h Hal) WithoutEmbedded(name string) Hal {
+	if _, ok := h.Embedded[name]; !ok {
+		return h
+	}
+	h.Embedded = copyMap(h.Embedded, 0)
+	delete(h.Embedded, name)
+	return h
+}
+
+// WithTemplate returns a copy of h with a HAL-FORMS template set,
+// see SetTemplate. The form itself is not copied
+func (h Hal) WithTemplate(name string, form *Form) Hal {
+	h.Templates = copyMap(h.Templates, 1)
+	h.SetTemplate(name, form)
+	return h
+}
+
+// WithoutTemplate returns a copy of h without a template named name
+func (h Hal) WithoutTemplate(name string) Hal {
+	if _, ok := h.Templates[name]; !ok {
+		return h
+	}
+	h.Templates = copyMap(h.Templates, 0)
+	delete(h.Templates, name)
+	return h
+}
+
+// Clone returns a deep copy of h. Links and forms are copied, embedded
+// resources embedding Hal are copied recursively along with slices and
+// generic maps holding them and their fields tagged as embedded, other
+// embedded values are shared
+func (h *Hal) Clone() Hal {
+	var clone Hal
+	if h.Links != nil {
+		clone.Links = make(map[string]LinkSet, len(h.Links))
+		for name, set := range h.Links {
+			clone.Links[name] = copyLinkSet(set)
+		}
+	}
+	if h.Embedded != nil {
+		clone.Embedded = make(map[string]Embedded, len(h.Embedded))
+		for name, embedded := range h.Embedded {
+			clone.Embedded[name] = cloneValue(reflect.ValueOf(embedded)).Interface()
+		}
+	}
+	if h.Templates != nil {
+		clone.Templates = make(map[string]*Form, len(h.Templates))
+		for name, form := range h.Templates {
+			clone.Templates[name] = form.Clone()
+		}
+	}
+	clone.arrays = copyMap(h.arrays, 0)
+	return clone
+}
+
+// Clone returns a deep copy of the form
+func (f *Form) Clone() *Form {
+	if f == nil {
+		return nil
+	}
+	clone := *f
+	if f.Properties == nil {
+		return &clone
+	}
+	clone.Properties = make([]*FormProperty, len(f.Properties))
+	for i, property := range f.Properties {
+		copied := *property
+		if property.Options != nil {
+			options := *property.Options
+			options.Inline = append([]Choice(nil), options.Inline...)
+			options.SelectedValues = append([]string(nil), options.SelectedValues...)
+			if options.Link != nil {
+				link := *options.Link
+				options.Link = &link
+			}
+			copied.Options = &options
+		}
+		clone.Properties[i] = &copied
+	}
+	return &clone
+}
+
+// cloneValue copies value if it is or holds a resource embedding Hal,
+// the value is returned as it is otherwise
+func cloneValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() || !value.Type().Implements(halResourceType) {
+			return value
+		}
+		clone := reflect.New(value.Type().Elem())
+		clone.Elem().Set(value.Elem())
+		cloneResource(clone)
+		return clone
+	case reflect.Struct:
+		if !reflect.PointerTo(value.Type()).Implements(halResourceType) {
+			return value
+		}
+		clone := reflect.New(value.Type())
+		clone.Elem().Set(value)
+		cloneResource(clone)
+		return clone.Elem()
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.New(value.Type()).Elem()
+		clone.Set(cloneValue(value.Elem()))
+		return clone
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		clone := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			clone.Index(i).Set(cloneValue(value.Index(i)))
+		}
+		return clone
+	case reflect.Map:
+		if value.IsNil() || value.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		clone := reflect.MakeMapWithSize(value.Type(), value.Len())
+		iter := value.MapRange()
+		for iter.Next() {
+			clone.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+		}
+		return clone
+	}
+	return value
+}
+
+// cloneResource replaces Hal of a freshly copied resource with its deep
+// copy along with fields tagged as embedded, properties of generic resources
+// are copied as well
+func cloneResource(ptr reflect.Value) {
+	if h := ptr.Interface().(halResource).hal(); h != nil {
+		clone := h.Clone()
+		// Hal embedded by pointer is still shared with the original
+		if field := ptr.Elem().FieldByName("Hal"); field.Kind() == reflect.Ptr && field.CanSet() {
+			field.Set(reflect.ValueOf(&clone))
+		} else {
+			*h = clone
+		}
+	}
+	if isTaggable(ptr.Type().Elem()) {
+		for _, embedded := range structTags(ptr.Type().Elem()).embedded {
+			if field, err := ptr.Elem().FieldByIndexErr(embedded.index); err == nil && field.CanSet() {
+				field.Set(cloneValue(field))
+			}
+		}
+	}
+	if resource, ok := ptr.Interface().(*Resource); ok {
+		resource.keys = append([]string(nil), resource.keys...)
+		resource.properties = copyMap(resource.properties, 0)
+	}
+}
+
+// copyLinks returns a copy of links with room for extra entries, link
+// sets are shared but clipped so appending to them does not affect links
+func copyLinks(links map[string]LinkSet, extra int) map[string]LinkSet {
+	copied := make(map[string]LinkSet, len(links)+extra)
+	for name, set := range links {
+		set.Links = set.Links[:len(set.Links):len(set.Links)]
+		copied[name] = set
+	}
+	return copied
+}
+
+// copyMap returns a shallow copy of m with room for extra entries
+func copyMap[V any](m map[string]V, extra int) map[string]V {
+	if m == nil && extra == 0 {
+		return nil
+	}
+	copied := make(map[string]V, len(m)+extra)
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,136 @@
+package jsonhal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMethods(t *testing.T) {
+	base := new(HelloWorld)
+	base.SetLink("self", "/v1/hello/world/1", "")
+	base.AddLink("item", "/v1/foo/bar/1", "")
+	base.SetEmbedded("foobar", &Foobar{ID: 1})
+	base.SetTemplate("default", NewForm("PUT", ""))
+	cached, err := json.Marshal(base)
+	assert.NoError(t, err)
+
+	// Test the base resource is left untouched
+	h := base.Hal.
+		WithLink("next", "/v1/hello/world/2", "").
+		WithAddedLink("item", "/v1/foo/bar/2", "").
+		WithoutLink("self").
+		WithEmbedded("qux", &Qux{ID: 1}).
+		WithoutEmbedded("foobar").
+		WithTemplate("delete", NewForm("DELETE", "")).
+		WithoutTemplate("default")
+	actual, err := json.Marshal(base)
+	assert.NoError(t, err)
+	assert.Equal(t, string(cached), string(actual))
+
+	items, err := h.GetLinks("item")
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	_, err = h.GetLink("self")
+	assert.Error(t, err)
+	_, err = h.GetLink("next")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Embedded{"qux": &Qux{ID: 1}}, h.Embedded)
+	assert.Equal(t, map[string]*Form{"delete": NewForm("DELETE", "")}, h.Templates)
+
+	// Test appending to copies sharing the same base links
+	first := base.Hal.WithAddedLink("item", "/v1/foo/bar/3", "")
+	second := base.Hal.WithAddedLink("item", "/v1/foo/bar/4", "")
+	assert.Equal(t, "/v1/foo/bar/3", first.Links["item"].Links[1].Href)
+	assert.Equal(t, "/v1/foo/bar/4", second.Links["item"].Links[1].Href)
+	base.AddLink("item", "/v1/foo/bar/5", "")
+	assert.Equal(t, "/v1/foo/bar/3", first.Links["item"].Links[1].Href)
+
+	// Test link array flags are not shared with the base
+	base.SetLinkArray("item", true)
+	cached, err = json.Marshal(base)
+	assert.NoError(t, err)
+	copied := base.Hal.WithLink("next", "/v1/hello/world/2", "")
+	copied.SetLinkArray("next", true)
+	copied = copied.WithLinkArray("self", true).WithLinkArray("item", false)
+	actual, err = json.Marshal(base)
+	assert.NoError(t, err)
+	assert.Equal(t, string(cached), string(actual))
+	base.SetLink("next", "/v1/hello/world/2", "")
+	assert.False(t, base.Links["next"].Array)
+	assert.True(t, copied.Links["self"].Array)
+	assert.False(t, copied.Links["item"].Array)
+	assert.True(t, copied.Links["next"].Array)
+
+	// Removing missing values returns the same value
+	empty := Hal{}
+	assert.Equal(t, empty, empty.WithoutLink("self").WithoutEmbedded("foobar").WithoutTemplate("default"))
+}
+
+func TestClone(t *testing.T) {
+	nested := &Foobar{ID: 2}
+	nested.SetLink("self", "/v1/foo/bar/2", "")
+	resource := NewResource()
+	resource.SetProperty("id", 3)
+
+	original := new(HelloWorld)
+	original.SetLink("self", "/v1/hello/world/1", "")
+	original.SetEmbedded("foobar", &Foobar{ID: 1})
+	original.SetEmbedded("foobars", []*Foobar{nested})
+	original.SetEmbedded("quxes", []Qux{{ID: 1}})
+	original.SetEmbedded("resource", resource)
+	original.SetEmbedded("generic", map[string]interface{}{"items": []interface{}{nested}})
+	original.SetTemplate("default", NewForm("PUT", "").AddProperty("color", InlineOptions(Choice{"Red", "red"})))
+
+	clone := &HelloWorld{Hal: original.Clone(), ID: original.ID}
+	assert.Equal(t, original, clone)
+
+	// Test modifying the clone does not affect the original
+	link, _ := clone.GetLink("self")
+	link.Href = "/v1/bogus"
+	clone.Embedded["foobar"].(*Foobar).ID = 100
+	clone.Embedded["foobars"].([]*Foobar)[0].SetLink("self", "/v1/bogus", "")
+	clone.Embedded["quxes"].([]Qux)[0].ID = 100
+	clone.Embedded["resource"].(*Resource).SetProperty("id", 100)
+	clone.Embedded["generic"].(map[string]interface{})["items"].([]interface{})[0].(*Foobar).ID = 100
+	clone.Templates["default"].Properties[0].Options.Inline[0].Value = "blue"
+
+	link, _ = original.GetLink("self")
+	assert.Equal(t, "/v1/hello/world/1", link.Href)
+	assert.Equal(t, uint(1), original.Embedded["foobar"].(*Foobar).ID)
+	link, _ = nested.GetLink("self")
+	assert.Equal(t, "/v1/foo/bar/2", link.Href)
+	assert.Equal(t, uint(2), nested.ID)
+	assert.Equal(t, uint(1), original.Embedded["quxes"].([]Qux)[0].ID)
+	value, _ := resource.GetProperty("id")
+	assert.Equal(t, 3, value)
+	assert.Equal(t, "red", original.Templates["default"].Properties[0].Options.Inline[0].Value)
+}
+
+func TestCloneEmbeddedTags(t *testing.T) {
+	original := new(HelloWorld)
+	customer := &embeddingCustomer{Name: "Bob", Orders: []*embeddingOrder{{ID: 1, Items: []Qux{{ID: 1}}}}}
+	pointer := &pointerEmbedding{Name: "Alice", Orders: []*embeddingOrder{{ID: 2}}}
+	withHal := &pointerEmbedding{Hal: new(Hal), Name: "Carol"}
+	withHal.SetLink("self", "/v1/customers/3", "")
+	original.SetEmbedded("customer", customer)
+	original.SetEmbedded("pointer", pointer)
+	original.SetEmbedded("withHal", withHal)
+
+	clone := original.Clone()
+	assert.Equal(t, original.Embedded, clone.Embedded)
+
+	// Test modifying tagged fields of the clone does not affect the original
+	clone.Embedded["customer"].(*embeddingCustomer).Orders[0].ID = 100
+	clone.Embedded["customer"].(*embeddingCustomer).Orders[0].Items[0].ID = 100
+	clone.Embedded["pointer"].(*pointerEmbedding).Orders[0].ID = 100
+	clone.Embedded["withHal"].(*pointerEmbedding).SetLink("self", "/v1/bogus", "")
+
+	assert.Equal(t, uint(1), customer.Orders[0].ID)
+	assert.Equal(t, uint(1), customer.Orders[0].Items[0].ID)
+	assert.Equal(t, uint(2), pointer.Orders[0].ID)
+	assert.Nil(t, pointer.Hal)
+	link, _ := withHal.GetLink("self")
+	assert.Equal(t, "/v1/customers/3", link.Href)
+}
@@ -0,0 +1,158 @@
+package jsonhal
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+)
+
+// Collection is a paginated collection resource with navigation links,
+// items embedded under a chosen name and count/total properties
+type Collection struct {
+	Hal
+	Count int `json:"count"`
+	Total int `json:"total"`
+}
+
+// Pagination describes the current page of a collection
+type Pagination interface {
+	// PageQueries returns query parameters of navigation links
+	// (self, first, last, next, previous) by link name. Links
+	// which do not apply to the current page are left out
+	PageQueries(count, total int) (map[string]url.Values, error)
+}
+
+// OffsetPage describes a page by offset of its first item and limit
+type OffsetPage struct {
+	Offset int
+	Limit  int
+}
+
+// PageQueries returns offset and limit query parameters of navigation links
+func (p OffsetPage) PageQueries(count, total int) (map[string]url.Values, error) {
+	if p.Offset < 0 {
+		return nil, fmt.Errorf("Invalid offset %d", p.Offset)
+	}
+	if p.Limit < 1 {
+		return nil, fmt.Errorf("Invalid limit %d", p.Limit)
+	}
+	query := func(offset int) url.Values {
+		return url.Values{
+			"offset": []string{fmt.Sprint(offset)},
+			"limit":  []string{fmt.Sprint(p.Limit)},
+		}
+	}
+	last := 0
+	if total > 0 {
+		last = (total - 1) / p.Limit * p.Limit
+	}
+	queries := map[string]url.Values{
+		"self":  query(p.Offset),
+		"first": query(0),
+		"last":  query(last),
+	}
+	if p.Offset+p.Limit < total {
+		queries["next"] = query(p.Offset + p.Limit)
+	}
+	if p.Offset > 0 {
+		previous := p.Offset - p.Limit
+		if previous < 0 {
+			previous = 0
+		}
+		queries["previous"] = query(previous)
+	}
+	return queries, nil
+}
+
+// NumberedPage describes a page by its number, starting with 1, and size
+type NumberedPage struct {
+	Page int
+	Size int
+}
+
+// PageQueries returns page and size query parameters of navigation links
+func (p NumberedPage) PageQueries(count, total int) (map[string]url.Values, error) {
+	if p.Page < 1 {
+		return nil, fmt.Errorf("Invalid page %d", p.Page)
+	}
+	if p.Size < 1 {
+		return nil, fmt.Errorf("Invalid size %d", p.Size)
+	}
+	query := func(page int) url.Values {
+		return url.Values{
+			"page": []string{fmt.Sprint(page)},
+			"size": []string{fmt.Sprint(p.Size)},
+		}
+	}
+	last := (total + p.Size - 1) / p.Size
+	if last < 1 {
+		last = 1
+	}
+	queries := map[string]url.Values{
+		"self":  query(p.Page),
+		"first": query(1),
+		"last":  query(last),
+	}
+	if p.Page < last {
+		queries["next"] = query(p.Page + 1)
+	}
+	if p.Page > 1 {
+		previous := p.Page - 1
+		if previous > last {
+			previous = last
+		}
+		queries["previous"] = query(previous)
+	}
+	return queries, nil
+}
+
+// paginationKeys are query parameters set by paginations of this package,
+// they are removed from the base URL so links of different pages do not mix
+var paginationKeys = []string{"cursor", "offset", "limit", "page", "size"}
+
+// NewCollection returns a collection of items embedded under name with
+// navigation links for the current page. Query parameters of baseURL,
+// filters for example, are kept in all links except for pagination
+// parameters such as "cursor" or "offset". A nil slice of items is
+// embedded as an empty one, nil items are not embedded at all
+func NewCollection(baseURL string, page Pagination, total int, name string, items Embedded) (*Collection, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	count := 0
+	if v := reflect.ValueOf(items); v.IsValid() {
+		switch {
+		case v.Kind() == reflect.Slice && v.IsNil():
+			items = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+		case (v.Kind() == reflect.Ptr || v.Kind() == reflect.Map) && v.IsNil():
+			items = nil
+		case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+			count = v.Len()
+		default:
+			count = 1
+		}
+	}
+	queries, err := page.PageQueries(count, total)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &Collection{Count: count, Total: total}
+	for rel, query := range queries {
+		href := *base
+		values := base.Query()
+		for _, key := range paginationKeys {
+			values.Del(key)
+		}
+		for key, value := range query {
+			values[key] = value
+		}
+		href.RawQuery = values.Encode()
+		collection.SetLink(rel, href.String(), "")
+	}
+	if items != nil {
+		collection.SetEmbedded(name, items)
+	}
+	return collection, nil
+}
@@ -0,0 +1,191 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var expectedCollectionJSON = []byte(`{
+	"_links": {
+		"first": {
+			"href": "/v1/foo/bar?limit=2\u0026name=foo\u0026offset=0"
+		},
+		"last": {
+			"href": "/v1/foo/bar?limit=2\u0026name=foo\u0026offset=4"
+		},
+		"next": {
+			"href": "/v1/foo/bar?limit=2\u0026name=foo\u0026offset=4"
+		},
+		"previous": {
+			"href": "/v1/foo/bar?limit=2\u0026name=foo\u0026offset=0"
+		},
+		"self": {
+			"href": "/v1/foo/bar?limit=2\u0026name=foo\u0026offset=2"
+		}
+	},
+	"_embedded": {
+		"foobars": [
+			{
+				"id": 3,
+				"name": "Foo bar 3"
+			},
+			{
+				"id": 4,
+				"name": "Foo bar 4"
+			}
+		]
+	},
+	"count": 2,
+	"total": 5
+}`)
+
+func TestNewCollection(t *testing.T) {
+	foobars := []*Foobar{
+		&Foobar{ID: 3, Name: "Foo bar 3"},
+		&Foobar{ID: 4, Name: "Foo bar 4"},
+	}
+	collection, err := NewCollection(
+		"/v1/foo/bar?name=foo&offset=10", // base URL
+		OffsetPage{Offset: 2, Limit: 2},  // current page
+		5,                                // total
+		"foobars",                        // name
+		Embedded(foobars),                // items
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Assert JSON after marshalling is as expected
+	expected := bytes.NewBuffer([]byte{})
+	err = json.Compact(expected, expectedCollectionJSON)
+	if err != nil {
+		log.Fatal(err)
+	}
+	actual, err := json.Marshal(collection)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, expected.String(), string(actual))
+
+	// Test pagination parameters of the base URL are not kept
+	collection, err = NewCollection("/v1/foo?cursor=abc&limit=2", CursorPage{Limit: 2, Next: "n"}, 0, "foos", []*Foobar{})
+	assert.NoError(t, err)
+	link, err := collection.GetLink("first")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/foo?limit=2", link.Href)
+	link, err = collection.GetLink("next")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/foo?cursor=n&limit=2", link.Href)
+
+	// Test typed nil items, a nil slice is embedded as an empty one
+	collection, err = NewCollection("/v1/foo/bar", OffsetPage{Offset: 0, Limit: 2}, 0, "foobars", []*Foobar(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, collection.Count)
+	actual, err = json.Marshal(collection.Embedded)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"foobars":[]}`, string(actual))
+	collection, err = NewCollection("/v1/foo/bar", OffsetPage{Offset: 0, Limit: 2}, 0, "foobar", (*Foobar)(nil))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, collection.Count)
+	assert.Nil(t, collection.Embedded)
+
+	// Test errors
+	collection, err = NewCollection("/v1/foo/bar", OffsetPage{Offset: 0, Limit: 0}, 5, "foobars", nil)
+	assert.Nil(t, collection)
+	assert.EqualError(t, err, "Invalid limit 0")
+
+	collection, err = NewCollection("%zz", OffsetPage{Offset: 0, Limit: 2}, 5, "foobars", nil)
+	assert.Nil(t, collection)
+	assert.Error(t, err)
+}
+
+func TestPageQueries(t *testing.T) {
+	testCases := []struct {
+		page     Pagination
+		total    int
+		expected map[string]string
+		err      string
+	}{
+		{
+			page:  OffsetPage{Offset: 0, Limit: 2},
+			total: 0,
+			expected: map[string]string{
+				"self":  "limit=2&offset=0",
+				"first": "limit=2&offset=0",
+				"last":  "limit=2&offset=0",
+			},
+		},
+		{
+			page:  OffsetPage{Offset: 1, Limit: 2},
+			total: 4,
+			expected: map[string]string{
+				"self":     "limit=2&offset=1",
+				"first":    "limit=2&offset=0",
+				"last":     "limit=2&offset=2",
+				"next":     "limit=2&offset=3",
+				"previous": "limit=2&offset=0",
+			},
+		},
+		{
+			page: OffsetPage{Offset: -1, Limit: 2},
+			err:  "Invalid offset -1",
+		},
+		{
+			page:  NumberedPage{Page: 1, Size: 10},
+			total: 25,
+			expected: map[string]string{
+				"self":  "page=1&size=10",
+				"first": "page=1&size=10",
+				"last":  "page=3&size=10",
+				"next":  "page=2&size=10",
+			},
+		},
+		{
+			page:  NumberedPage{Page: 3, Size: 10},
+			total: 25,
+			expected: map[string]string{
+				"self":     "page=3&size=10",
+				"first":    "page=1&size=10",
+				"last":     "page=3&size=10",
+				"previous": "page=2&size=10",
+			},
+		},
+		{
+			page:  NumberedPage{Page: 7, Size: 10},
+			total: 25,
+			expected: map[string]string{
+				"self":     "page=7&size=10",
+				"first":    "page=1&size=10",
+				"last":     "page=3&size=10",
+				"previous": "page=3&size=10",
+			},
+		},
+		{
+			page: NumberedPage{Page: 0, Size: 10},
+			err:  "Invalid page 0",
+		},
+		{
+			page: NumberedPage{Page: 1, Size: 0},
+			err:  "Invalid size 0",
+		},
+	}
+
+	for _, testCase := range testCases {
+		queries, err := testCase.page.PageQueries(0, testCase.total)
+		if testCase.err != "" {
+			assert.Nil(t, queries)
+			assert.EqualError(t, err, testCase.err)
+			continue
+		}
+		assert.NoError(t, err)
+		actual := make(map[string]string, len(queries))
+		for rel, query := range queries {
+			actual[rel] = query.Encode()
+		}
+		assert.Equal(t, testCase.expected, actual, "%#v", testCase.page)
+	}
+}
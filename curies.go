@@ -0,0 +1,86 @@
+package jsonhal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CuriesRel is the reserved link relation holding CURIE definitions
+const CuriesRel = "curies"
+
+// AddCurie registers a CURIE named name so compact link relations such as
+// "name:widgets" can be resolved to documentation URLs. Href must be
+// a URI template with a "rel" variable, e.g. "http://example.com/docs/{rel}".
+// CURIEs are emitted as a templated "curies" array in "_links" object
+func (h *Hal) AddCurie(name, href string) {
+	if h.Links == nil {
+		h.Links = make(map[string]LinkSet, 0)
+	}
+	set := h.Links[CuriesRel]
+	curie := &Link{Href: href, Templated: true, Name: name}
+	for i, link := range set.Links {
+		if link.Name == name {
+			set.Links = append(set.Links[:i:i], set.Links[i+1:]...)
+			break
+		}
+	}
+	set.Links = append(set.Links, curie)
+	set.Array = true
+	h.Links[CuriesRel] = set
+}
+
+// GetCurie returns a CURIE by name or error
+func (h *Hal) GetCurie(name string) (*Link, error) {
+	for _, link := range h.Links[CuriesRel].Links {
+		if link.Name == name {
+			return link, nil
+		}
+	}
+	return nil, &NotFoundError{Kind: ErrCurieNotFound, Name: name}
+}
+
+// ValidateCuries returns an error if any compact link relation in "_links"
+// object uses a CURIE which has not been registered. WriteResource and
+// Negotiate call it before writing a resource, other encoders do not
+func (h *Hal) ValidateCuries() error {
+	rels := make([]string, 0, len(h.Links))
+	for rel := range h.Links {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	for _, rel := range rels {
+		prefix, _, ok := splitCurie(rel)
+		if !ok {
+			continue
+		}
+		if _, err := h.GetCurie(prefix); err != nil {
+			return invalidDocument(fmt.Errorf("Link \"%s\" uses unregistered CURIE \"%s\"", rel, prefix))
+		}
+	}
+	return nil
+}
+
+// ExpandRel expands a compact link relation into its full URI using
+// the registered CURIEs. Relations which are not compact are returned as is
+func (h *Hal) ExpandRel(rel string) (string, error) {
+	prefix, reference, ok := splitCurie(rel)
+	if !ok {
+		return rel, nil
+	}
+	curie, err := h.GetCurie(prefix)
+	if err != nil {
+		return "", err
+	}
+	return curie.Expand(map[string]interface{}{"rel": reference})
+}
+
+// splitCurie splits a compact link relation into prefix and reference,
+// registered relations and absolute URIs are not compact
+func splitCurie(rel string) (prefix, reference string, ok bool) {
+	i := strings.Index(rel, ":")
+	if i < 1 || strings.HasPrefix(rel[i+1:], "//") {
+		return "", "", false
+	}
+	return rel[:i], rel[i+1:], true
+}
@@ -0,0 +1,81 @@
+package jsonhal
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"encoding/json"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var expectedCuriesJSON = []byte(`{
+	"_links": {
+		"acme:widgets": {
+			"href": "/v1/widgets"
+		},
+		"curies": [
+			{
+				"href": "http://docs.acme.com/rels/{rel}",
+				"templated": true,
+				"name": "acme"
+			}
+		],
+		"self": {
+			"href": "/v1/hello/world/1"
+		}
+	},
+	"id": 1,
+	"name": "Hello World"
+}`)
+
+func TestCuries(t *testing.T) {
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	helloWorld.SetLink("acme:widgets", "/v1/widgets", "")
+
+	// Test validating without the CURIE registered
+	assert.EqualError(
+		t,
+		helloWorld.ValidateCuries(),
+		"Link \"acme:widgets\" uses unregistered CURIE \"acme\"",
+	)
+
+	// Registering the same CURIE twice replaces it
+	helloWorld.AddCurie("acme", "http://docs.acme.com/{rel}")
+	helloWorld.AddCurie("acme", "http://docs.acme.com/rels/{rel}")
+	assert.NoError(t, helloWorld.ValidateCuries())
+
+	// Assert JSON after marshalling is as expected
+	expected := bytes.NewBuffer([]byte{})
+	err := json.Compact(expected, expectedCuriesJSON)
+	if err != nil {
+		log.Fatal(err)
+	}
+	actual, err := json.Marshal(helloWorld)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, expected.String(), string(actual))
+
+	// Test expanding relations in a decoded document
+	decoded := new(HelloWorld)
+	assert.NoError(t, json.Unmarshal(actual, decoded))
+
+	rel, err := decoded.ExpandRel("acme:widgets")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://docs.acme.com/rels/widgets", rel)
+
+	rel, err = decoded.ExpandRel("self")
+	assert.NoError(t, err)
+	assert.Equal(t, "self", rel)
+
+	rel, err = decoded.ExpandRel("http://example.com/rels/widgets")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com/rels/widgets", rel)
+
+	rel, err = decoded.ExpandRel("bogus:widgets")
+	assert.Equal(t, "", rel)
+	assert.EqualError(t, err, "CURIE \"bogus\" not found")
+}
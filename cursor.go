@@ -0,0 +1,143 @@
+package jsonhal
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Cursor is the decoded content of an opaque cursor token, it holds the key
+// of the item a page starts after (or ends before when Backward is set)
+type Cursor struct {
+	Key      json.RawMessage `json:"k"`
+	Backward bool            `json:"b,omitempty"`
+}
+
+// DecodeKey decodes the cursor key into v
+func (c *Cursor) DecodeKey(v interface{}) error {
+	return json.Unmarshal(c.Key, v)
+}
+
+// CursorCodec encodes and decodes opaque cursor tokens. When Secret is set
+// tokens are signed with HMAC-SHA256 so clients cannot forge them
+type CursorCodec struct {
+	Secret []byte
+}
+
+var cursorEncoding = base64.RawURLEncoding
+
+// Encode returns a cursor token for an item key, the key can be any value
+// encoding/json can marshal, a struct of sort columns for example
+func (c CursorCodec) Encode(key interface{}, backward bool) (string, error) {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(Cursor{Key: data, Backward: backward})
+	if err != nil {
+		return "", err
+	}
+	token := cursorEncoding.EncodeToString(payload)
+	if c.Secret != nil {
+		token += "." + cursorEncoding.EncodeToString(c.sign(payload))
+	}
+	return token, nil
+}
+
+// Decode decodes and verifies a cursor token, malformed tokens and tokens
+// with an invalid signature result in an error matching ErrInvalidCursor
+func (c CursorCodec) Decode(token string) (*Cursor, error) {
+	encoded, signature := token, ""
+	if c.Secret != nil {
+		i := strings.LastIndexByte(token, '.')
+		if i == -1 {
+			return nil, invalidCursor(token)
+		}
+		encoded, signature = token[:i], token[i+1:]
+	}
+	payload, err := cursorEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, invalidCursor(token)
+	}
+	if c.Secret != nil {
+		expected, err := cursorEncoding.DecodeString(signature)
+		if err != nil || !hmac.Equal(expected, c.sign(payload)) {
+			return nil, invalidCursor(token)
+		}
+	}
+	cursor := new(Cursor)
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cursor); err != nil || cursor.Key == nil {
+		return nil, invalidCursor(token)
+	}
+	return cursor, nil
+}
+
+func (c CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func invalidCursor(token string) error {
+	return fmt.Errorf("Invalid cursor \"%s\": %w", token, ErrInvalidCursor)
+}
+
+// NewPage returns the current page described by a cursor token (empty for
+// the first page) with cursors of the next and previous pages built from
+// keys of the first and last items of the current page
+func (c CursorCodec) NewPage(cursor string, limit int, firstKey, lastKey interface{}, hasPrevious, hasNext bool) (CursorPage, error) {
+	var err error
+	page := CursorPage{Cursor: cursor, Limit: limit}
+	if hasNext {
+		if page.Next, err = c.Encode(lastKey, false); err != nil {
+			return page, err
+		}
+	}
+	if hasPrevious {
+		if page.Previous, err = c.Encode(firstKey, true); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
+
+// CursorPage describes a page by an opaque cursor token
+type CursorPage struct {
+	Cursor   string // cursor of the current page, empty for the first page
+	Limit    int
+	Next     string // cursor of the next page, empty when there is none
+	Previous string // cursor of the previous page, empty when there is none
+}
+
+// PageQueries returns cursor and limit query parameters of navigation
+// links, there is no last link as cursors do not allow to jump to it
+func (p CursorPage) PageQueries(count, total int) (map[string]url.Values, error) {
+	if p.Limit < 1 {
+		return nil, fmt.Errorf("Invalid limit %d", p.Limit)
+	}
+	query := func(cursor string) url.Values {
+		values := url.Values{"limit": []string{fmt.Sprint(p.Limit)}}
+		if cursor != "" {
+			values.Set("cursor", cursor)
+		}
+		return values
+	}
+	queries := map[string]url.Values{
+		"self":  query(p.Cursor),
+		"first": query(""),
+	}
+	if p.Next != "" {
+		queries["next"] = query(p.Next)
+	}
+	if p.Previous != "" {
+		queries["previous"] = query(p.Previous)
+	}
+	return queries, nil
+}
@@ -0,0 +1,100 @@
+package jsonhal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorCodec(t *testing.T) {
+	var (
+		cursor *Cursor
+		token  string
+		err    error
+	)
+
+	type key struct {
+		CreatedAt string `json:"created_at"`
+		ID        uint   `json:"id"`
+	}
+
+	for _, codec := range []CursorCodec{{}, {Secret: []byte("secret")}} {
+		// Test a round trip
+		token, err = codec.Encode(key{CreatedAt: "2016-09-28", ID: 2}, true)
+		assert.NoError(t, err)
+		cursor, err = codec.Decode(token)
+		if assert.NoError(t, err) {
+			assert.True(t, cursor.Backward)
+			decoded := key{}
+			assert.NoError(t, cursor.DecodeKey(&decoded))
+			assert.Equal(t, key{CreatedAt: "2016-09-28", ID: 2}, decoded)
+		}
+
+		// Test malformed tokens
+		for _, invalid := range []string{"", "bogus", "e30", token[:len(token)-2], "!" + token} {
+			cursor, err = codec.Decode(invalid)
+			assert.Nil(t, cursor)
+			assert.True(t, errors.Is(err, ErrInvalidCursor), invalid)
+		}
+	}
+
+	// Test tokens signed with a different secret are rejected
+	token, err = CursorCodec{Secret: []byte("bogus")}.Encode(1, false)
+	assert.NoError(t, err)
+	cursor, err = CursorCodec{Secret: []byte("secret")}.Decode(token)
+	assert.Nil(t, cursor)
+	assert.EqualError(t, err, "Invalid cursor \""+token+"\": invalid cursor")
+
+	// Test unsigned tokens are rejected when a secret is set
+	token, err = CursorCodec{}.Encode(1, false)
+	assert.NoError(t, err)
+	_, err = CursorCodec{Secret: []byte("secret")}.Decode(token)
+	assert.True(t, errors.Is(err, ErrInvalidCursor))
+}
+
+func TestCursorPage(t *testing.T) {
+	codec := CursorCodec{Secret: []byte("secret")}
+
+	// Test the first page
+	page, err := codec.NewPage("", 2, 1, 2, false, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "", page.Previous)
+	cursor, err := codec.Decode(page.Next)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "2", string(cursor.Key))
+		assert.False(t, cursor.Backward)
+	}
+
+	collection, err := NewCollection("/v1/foo/bar", page, 0, "foobars", []*Foobar{})
+	assert.NoError(t, err)
+	assert.Len(t, collection.Links, 3)
+	link, err := collection.GetLink("self")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/foo/bar?limit=2", link.Href)
+	link, err = collection.GetLink("next")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/foo/bar?cursor="+page.Next+"&limit=2", link.Href)
+
+	// Test a page in the middle
+	page, err = codec.NewPage(page.Next, 2, 3, 4, true, true)
+	assert.NoError(t, err)
+	cursor, err = codec.Decode(page.Previous)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "3", string(cursor.Key))
+		assert.True(t, cursor.Backward)
+	}
+	queries, err := page.PageQueries(2, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, page.Cursor, queries["self"].Get("cursor"))
+	assert.Equal(t, page.Next, queries["next"].Get("cursor"))
+	assert.Equal(t, page.Previous, queries["previous"].Get("cursor"))
+	assert.Equal(t, "", queries["first"].Get("cursor"))
+	assert.NotContains(t, queries, "last")
+
+	// Test errors
+	_, err = codec.NewPage("", 2, 1, func() {}, false, true)
+	assert.Error(t, err)
+	_, err = CursorPage{Limit: 0}.PageQueries(0, 0)
+	assert.EqualError(t, err, "Invalid limit 0")
+}
@@ -0,0 +1,168 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// EmbeddedTypes maps names of embedded resources to prototype values
+// of the Go types they decode into, for example:
+//
+//	EmbeddedTypes{"foobar": (*Foobar)(nil), "foobars": []*Foobar(nil)}
+type EmbeddedTypes map[string]interface{}
+
+// halResource is implemented by all structs embedding Hal
+type halResource interface {
+	hal() *Hal
+}
+
+func (h *Hal) hal() *Hal {
+	return h
+}
+
+// Unmarshal decodes a HAL document into v, which should be a pointer to
+// a struct embedding Hal. Embedded resources listed in types are decoded
+// into their Go types rather than generic maps, the same types are used for
+// resources embedded in the decoded resources. Embedded resources which are
+// not listed in types are decoded as usual by encoding/json. Embedded resources
+// are moved into fields tagged with `hal:"embedded=rel"`, see Marshal
+func Unmarshal(data []byte, v interface{}, types EmbeddedTypes) error {
+	for name, prototype := range types {
+		if prototype == nil {
+			return fmt.Errorf("Embedded \"%s\" has no type", name)
+		}
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return invalidDocument(err)
+	}
+	return invalidDocument(decodeEmbedded(data, v, types))
+}
+
+// decodeEmbedded moves embedded resources of v into fields tagged
+// with `hal:"embedded=rel"` and replaces the rest with values of types
+// registered for them
+func decodeEmbedded(data []byte, v interface{}, types EmbeddedTypes) error {
+	resource, ok := v.(halResource)
+	if !ok {
+		return nil
+	}
+	var declared *typeTags
+	if value := reflect.ValueOf(v); value.Kind() == reflect.Ptr && isTaggable(value.Type().Elem()) {
+		if declared = structTags(value.Type().Elem()); declared.err != nil {
+			return declared.err
+		}
+	}
+	if len(types) == 0 && (declared == nil || len(declared.embedded) == 0) {
+		return nil
+	}
+	h := resource.hal()
+	if h == nil || len(h.Embedded) == 0 {
+		return nil
+	}
+	var document struct {
+		Embedded map[string]json.RawMessage `json:"_embedded"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	if declared != nil {
+		for _, embedded := range declared.embedded {
+			raw, ok := document.Embedded[embedded.rel]
+			if !ok {
+				continue
+			}
+			if err := decodeTaggedField(raw, reflect.ValueOf(v).Elem(), embedded, types); err != nil {
+				return fmt.Errorf("Embedded \"%s\": %w", embedded.rel, err)
+			}
+			delete(document.Embedded, embedded.rel)
+			delete(h.Embedded, embedded.rel)
+		}
+		if len(h.Embedded) == 0 {
+			h.Embedded = nil
+		}
+	}
+	for name, raw := range document.Embedded {
+		prototype, ok := types[name]
+		if !ok {
+			continue
+		}
+		embedded, err := decodeValue(raw, reflect.TypeOf(prototype), types)
+		if err != nil {
+			return fmt.Errorf("Embedded \"%s\": %w", name, err)
+		}
+		h.Embedded[name] = embedded
+	}
+	return nil
+}
+
+// decodeTaggedField decodes an embedded resource into a field of value,
+// a single resource is decoded as one item of a slice
+func decodeTaggedField(data []byte, value reflect.Value, embedded *tagEmbedded, types EmbeddedTypes) error {
+	field := value
+	for _, i := range embedded.index {
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		field = field.Field(i)
+	}
+	data = bytes.TrimSpace(data)
+	if field.Kind() == reflect.Slice && len(data) > 0 && data[0] == '{' {
+		data = append(append([]byte{'['}, data...), ']')
+	}
+	decoded, err := decodeValue(data, field.Type(), types)
+	if err != nil {
+		return err
+	}
+	if decoded == nil {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	field.Set(reflect.ValueOf(decoded))
+	return nil
+}
+
+// decodeValue decodes data into a new value of type t
+func decodeValue(data []byte, t reflect.Type, types EmbeddedTypes) (Embedded, error) {
+	value := reflect.New(t)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, err
+	}
+	if err := decodeNested(data, value.Elem(), types); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface(), nil
+}
+
+// decodeNested decodes embedded resources of resources nested in value
+func decodeNested(data []byte, value reflect.Value, types EmbeddedTypes) error {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		if value.Len() == 0 {
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		for i := 0; i < value.Len() && i < len(items); i++ {
+			if err := decodeNested(items[i], value.Index(i), types); err != nil {
+				return err
+			}
+		}
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return decodeEmbedded(data, value.Interface(), types)
+	case reflect.Struct:
+		if value.CanAddr() {
+			return decodeEmbedded(data, value.Addr().Interface(), types)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package jsonhal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var nestedEmbeddedJSON = []byte(`{
+	"_embedded": {
+		"foobar": {
+			"_links": {
+				"self": {
+					"href": "/v1/foo/bar/1"
+				}
+			},
+			"_embedded": {
+				"quxes": [
+					{
+						"id": 1,
+						"name": "Qux 1"
+					}
+				]
+			},
+			"id": 1,
+			"name": "Foo bar 1"
+		},
+		"bogus": {
+			"id": 2
+		}
+	},
+	"id": 1,
+	"name": "Hello World"
+}`)
+
+func TestUnmarshal(t *testing.T) {
+	var (
+		helloWorld *HelloWorld
+		embedded   Embedded
+		err        error
+	)
+
+	// Test a document with multiple embedded resources decodes back into
+	// the same structs which produced it
+	expected := &HelloWorld{ID: 1, Name: "Hello World"}
+	expected.SetLink("self", "/v1/hello/world/1", "")
+	expected.SetEmbedded("foobars", Embedded([]*Foobar{
+		&Foobar{Hal: Hal{Links: map[string]LinkSet{"self": LinkSet{Links: []*Link{&Link{Href: "/v1/foo/bar/1"}}}}}, ID: 1, Name: "Foo bar 1"},
+		&Foobar{Hal: Hal{Links: map[string]LinkSet{"self": LinkSet{Links: []*Link{&Link{Href: "/v1/foo/bar/2"}}}}}, ID: 2, Name: "Foo bar 2"},
+	}))
+	expected.SetEmbedded("quxes", Embedded([]Qux{
+		Qux{Hal: Hal{Links: map[string]LinkSet{"self": LinkSet{Links: []*Link{&Link{Href: "/v1/qux/1"}}}}}, ID: 1, Name: "Qux 1"},
+		Qux{Hal: Hal{Links: map[string]LinkSet{"self": LinkSet{Links: []*Link{&Link{Href: "/v1/qux/2"}}}}}, ID: 2, Name: "Qux 2"},
+	}))
+
+	helloWorld = new(HelloWorld)
+	err = Unmarshal(expectedJSON5, helloWorld, EmbeddedTypes{
+		"foobars": []*Foobar(nil),
+		"quxes":   []Qux(nil),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, helloWorld)
+
+	// Test nested embedded resources are decoded using the same types and
+	// resources without registered type are decoded as usual
+	helloWorld = new(HelloWorld)
+	err = Unmarshal(nestedEmbeddedJSON, helloWorld, EmbeddedTypes{
+		"foobar": (*Foobar)(nil),
+		"quxes":  []*Qux(nil),
+	})
+	assert.NoError(t, err)
+
+	embedded, err = helloWorld.GetEmbedded("foobar")
+	assert.NoError(t, err)
+	if foobar, ok := embedded.(*Foobar); assert.True(t, ok) {
+		assert.Equal(t, "Foo bar 1", foobar.Name)
+		embedded, err = foobar.GetEmbedded("quxes")
+		assert.NoError(t, err)
+		assert.Equal(t, []*Qux{&Qux{ID: 1, Name: "Qux 1"}}, embedded)
+	}
+
+	embedded, err = helloWorld.GetEmbedded("bogus")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": float64(2)}, embedded)
+
+	// Test errors
+	err = Unmarshal(nestedEmbeddedJSON, new(HelloWorld), EmbeddedTypes{"foobar": nil})
+	assert.EqualError(t, err, "Embedded \"foobar\" has no type")
+
+	err = Unmarshal(nestedEmbeddedJSON, new(HelloWorld), EmbeddedTypes{"foobar": []*Foobar(nil)})
+	assert.EqualError(t, err, "Embedded \"foobar\": json: cannot unmarshal object into Go value of type []*jsonhal.Foobar")
+
+	err = Unmarshal([]byte(`{"id": "1"}`), new(HelloWorld), nil)
+	assert.Error(t, err)
+}
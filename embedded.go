@@ -0,0 +1,116 @@
+package jsonhal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// EmbeddedTypeError is returned when an embedded resource
+// cannot be converted into the requested type
+type EmbeddedTypeError struct {
+	Name   string       // name of the embedded resource
+	Type   reflect.Type // requested type
+	Actual reflect.Type // type of the embedded resource
+	Err    error        // conversion error, if any
+}
+
+func (e *EmbeddedTypeError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("Embedded \"%s\" cannot be converted to %s: %s", e.Name, e.Type, e.Err)
+	}
+	return fmt.Sprintf("Embedded \"%s\" is %s, not %s", e.Name, e.Actual, e.Type)
+}
+
+// Is reports whether target is ErrTypeMismatch
+func (e *EmbeddedTypeError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// Unwrap returns the conversion error
+func (e *EmbeddedTypeError) Unwrap() error {
+	return e.Err
+}
+
+// GetEmbeddedAs returns an embedded resource by name as a value of type T.
+// Generic data decoded by encoding/json is converted into T, any other value
+// of a different type results in *EmbeddedTypeError
+func GetEmbeddedAs[T any](e EmbedGetter, name string) (T, error) {
+	var value T
+	embedded, err := e.GetEmbedded(name)
+	if err != nil {
+		return value, err
+	}
+	if typed, ok := embedded.(T); ok {
+		return typed, nil
+	}
+	if err := convertEmbedded(embedded, &value); err != nil {
+		var zero T
+		return zero, newEmbeddedTypeError(name, reflect.TypeOf((*T)(nil)).Elem(), embedded, err)
+	}
+	return value, nil
+}
+
+// GetEmbeddedSlice returns an embedded resource by name as a slice of T.
+// A single resource is returned as a slice of one item, items are converted
+// the same way as by GetEmbeddedAs
+func GetEmbeddedSlice[T any](e EmbedGetter, name string) ([]T, error) {
+	embedded, err := e.GetEmbedded(name)
+	if err != nil {
+		return nil, err
+	}
+	switch typed := embedded.(type) {
+	case []T:
+		return typed, nil
+	case T:
+		return []T{typed}, nil
+	}
+
+	value := reflect.ValueOf(embedded)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		var item T
+		if err := convertEmbedded(embedded, &item); err != nil {
+			return nil, newEmbeddedTypeError(name, reflect.TypeOf([]T(nil)), embedded, err)
+		}
+		return []T{item}, nil
+	}
+	items := make([]T, value.Len())
+	for i := range items {
+		item := value.Index(i).Interface()
+		if typed, ok := item.(T); ok {
+			items[i] = typed
+			continue
+		}
+		if err := convertEmbedded(item, &items[i]); err != nil {
+			return nil, newEmbeddedTypeError(name, reflect.TypeOf([]T(nil)), embedded, err)
+		}
+	}
+	return items, nil
+}
+
+// errNotConvertible signals a value is not generic data
+// so it cannot be converted to another type
+var errNotConvertible = errors.New("not convertible")
+
+// convertEmbedded converts generic data decoded by encoding/json into target
+func convertEmbedded(embedded Embedded, target interface{}) error {
+	switch embedded.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return errNotConvertible
+	}
+	data, err := json.Marshal(embedded)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+func newEmbeddedTypeError(name string, t reflect.Type, embedded Embedded, err error) error {
+	typeErr := &EmbeddedTypeError{Name: name, Type: t, Actual: reflect.TypeOf(embedded)}
+	if err != errNotConvertible {
+		typeErr.Err = err
+	}
+	return typeErr
+}
@@ -0,0 +1,118 @@
+package jsonhal
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEmbeddedAs(t *testing.T) {
+	var (
+		helloWorld *HelloWorld
+		foobar     *Foobar
+		typeErr    *EmbeddedTypeError
+		err        error
+	)
+
+	helloWorld = new(HelloWorld)
+	helloWorld.SetEmbedded("foobar", Embedded(&Foobar{ID: 1, Name: "Foo bar 1"}))
+
+	// Test getting a resource of the right type
+	foobar, err = GetEmbeddedAs[*Foobar](helloWorld, "foobar")
+	assert.NoError(t, err)
+	assert.Equal(t, &Foobar{ID: 1, Name: "Foo bar 1"}, foobar)
+
+	// Test getting a resource of a wrong type
+	qux, err := GetEmbeddedAs[*Qux](helloWorld, "foobar")
+	assert.Nil(t, qux)
+	if assert.True(t, errors.As(err, &typeErr)) {
+		assert.Equal(t, "foobar", typeErr.Name)
+		assert.Equal(t, reflect.TypeOf(new(Qux)), typeErr.Type)
+		assert.Equal(t, reflect.TypeOf(new(Foobar)), typeErr.Actual)
+	}
+	assert.EqualError(t, err, "Embedded \"foobar\" is *jsonhal.Foobar, not *jsonhal.Qux")
+
+	// Test getting a missing resource
+	foobar, err = GetEmbeddedAs[*Foobar](helloWorld, "bogus")
+	assert.Nil(t, foobar)
+	assert.False(t, errors.As(err, &typeErr))
+	assert.EqualError(t, err, "Embedded \"bogus\" not found")
+
+	// Test converting generic decoded data
+	helloWorld = new(HelloWorld)
+	assert.NoError(t, json.Unmarshal(expectedJSON3, helloWorld))
+	foobar, err = GetEmbeddedAs[*Foobar](helloWorld, "foobar")
+	assert.NoError(t, err)
+	if assert.NotNil(t, foobar) {
+		assert.Equal(t, uint(1), foobar.ID)
+		assert.Equal(t, "Foo bar 1", foobar.Name)
+	}
+	link, err := foobar.GetLink("self")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/foo/bar/1", link.Href)
+
+	// Test converting generic data which does not fit the type
+	number, err := GetEmbeddedAs[int](helloWorld, "foobar")
+	assert.Equal(t, 0, number)
+	if assert.True(t, errors.As(err, &typeErr)) {
+		assert.Error(t, typeErr.Err)
+	}
+}
+
+func TestGetEmbeddedSlice(t *testing.T) {
+	var (
+		helloWorld *HelloWorld
+		foobars    []*Foobar
+		typeErr    *EmbeddedTypeError
+		err        error
+	)
+
+	helloWorld = new(HelloWorld)
+	helloWorld.SetEmbedded("foobars", Embedded([]*Foobar{
+		&Foobar{ID: 1, Name: "Foo bar 1"},
+		&Foobar{ID: 2, Name: "Foo bar 2"},
+	}))
+	helloWorld.SetEmbedded("foobar", Embedded(&Foobar{ID: 3, Name: "Foo bar 3"}))
+	helloWorld.SetEmbedded("mixed", Embedded([]Embedded{
+		&Foobar{ID: 4, Name: "Foo bar 4"},
+		&Qux{ID: 1, Name: "Qux 1"},
+	}))
+
+	// Test getting a slice of the right type
+	foobars, err = GetEmbeddedSlice[*Foobar](helloWorld, "foobars")
+	assert.NoError(t, err)
+	assert.Len(t, foobars, 2)
+
+	// Test a single resource is returned as a slice
+	foobars, err = GetEmbeddedSlice[*Foobar](helloWorld, "foobar")
+	assert.NoError(t, err)
+	assert.Equal(t, []*Foobar{&Foobar{ID: 3, Name: "Foo bar 3"}}, foobars)
+
+	// Test getting a slice of a wrong type
+	quxes, err := GetEmbeddedSlice[*Qux](helloWorld, "foobars")
+	assert.Nil(t, quxes)
+	assert.True(t, errors.As(err, &typeErr))
+	assert.EqualError(t, err, "Embedded \"foobars\" is []*jsonhal.Foobar, not []*jsonhal.Qux")
+
+	foobars, err = GetEmbeddedSlice[*Foobar](helloWorld, "mixed")
+	assert.Nil(t, foobars)
+	assert.True(t, errors.As(err, &typeErr))
+
+	// Test getting a missing resource
+	foobars, err = GetEmbeddedSlice[*Foobar](helloWorld, "bogus")
+	assert.Nil(t, foobars)
+	assert.False(t, errors.As(err, &typeErr))
+	assert.EqualError(t, err, "Embedded \"bogus\" not found")
+
+	// Test converting generic decoded data
+	helloWorld = new(HelloWorld)
+	assert.NoError(t, json.Unmarshal(expectedJSON5, helloWorld))
+	quxes, err = GetEmbeddedSlice[*Qux](helloWorld, "quxes")
+	assert.NoError(t, err)
+	if assert.Len(t, quxes, 2) {
+		assert.Equal(t, "Qux 2", quxes[1].Name)
+	}
+}
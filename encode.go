@@ -0,0 +1,486 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+var (
+	halResourceType = reflect.TypeOf((*halResource)(nil)).Elem()
+	resourceType    = reflect.TypeOf(Resource{})
+)
+
+// Marshal returns the HAL JSON encoding of v. Unlike json.Marshal it moves
+// fields tagged with `hal:"embedded=rel"` into "_embedded" object, a nil
+// pointer or slice is left out. The same rules apply to embedded resources
+// recursively, including those set with SetEmbedded
+func Marshal(v interface{}) ([]byte, error) {
+	return marshalValue(reflect.ValueOf(v))
+}
+
+// marshalValue encodes value, structs embedding Hal and slices
+// of them are encoded with marshalResource
+func marshalValue(value reflect.Value) ([]byte, error) {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return []byte("null"), nil
+		}
+		if value.Kind() == reflect.Ptr && isTaggable(value.Type().Elem()) {
+			return marshalResource(value)
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Invalid:
+		return []byte("null"), nil
+	case reflect.Struct:
+		if isTaggable(value.Type()) {
+			ptr := reflect.New(value.Type())
+			ptr.Elem().Set(value)
+			return marshalResource(ptr)
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return []byte("null"), nil
+		}
+		if needsMarshalValue(value.Type().Elem()) {
+			items := make([]json.RawMessage, value.Len())
+			for i := range items {
+				item, err := marshalValue(value.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				items[i] = item
+			}
+			return json.Marshal(items)
+		}
+	}
+	return json.Marshal(value.Interface())
+}
+
+// marshalResource encodes ptr, a pointer to a struct embedding Hal, moving
+// fields tagged as embedded into "_embedded" object
+func marshalResource(ptr reflect.Value) ([]byte, error) {
+	declared := structTags(ptr.Type().Elem())
+	if declared.err != nil {
+		return nil, declared.err
+	}
+	// A struct embedding a nil *Hal has no links nor embedded resources
+	nested := make(map[string]Embedded)
+	if h := ptr.Interface().(halResource).hal(); h != nil {
+		for name, embedded := range h.Embedded {
+			if embedded != nil && needsMarshalValue(reflect.TypeOf(embedded)) {
+				nested[name] = embedded
+			}
+		}
+	}
+	if len(declared.embedded) == 0 && len(nested) == 0 {
+		return json.Marshal(ptr.Interface())
+	}
+
+	data, err := json.Marshal(ptr.Interface())
+	if err != nil {
+		return nil, err
+	}
+	resource := NewResource()
+	if err := json.Unmarshal(data, resource); err != nil {
+		return nil, err
+	}
+	for name, embedded := range nested {
+		data, err := marshalValue(reflect.ValueOf(embedded))
+		if err != nil {
+			return nil, err
+		}
+		resource.SetEmbedded(name, json.RawMessage(data))
+	}
+	for _, embedded := range declared.embedded {
+		if embedded.name != "" {
+			resource.DeleteProperty(embedded.name)
+		}
+		field, err := ptr.Elem().FieldByIndexErr(embedded.index)
+		if err != nil || (field.Kind() != reflect.Struct && field.IsNil()) {
+			continue
+		}
+		data, err := marshalValue(field)
+		if err != nil {
+			return nil, err
+		}
+		resource.SetEmbedded(embedded.rel, json.RawMessage(data))
+	}
+	return json.Marshal(resource)
+}
+
+// isTaggable reports whether t is a struct embedding Hal which can have
+// hal struct tags, generic resources are encoded as usual
+func isTaggable(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != resourceType && reflect.PointerTo(t).Implements(halResourceType)
+}
+
+// needsMarshalValue reports whether values of type t can contain
+// structs embedding Hal which have to be encoded with marshalValue
+func needsMarshalValue(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return false
+		}
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Interface || isTaggable(t)
+}
+
+// Section is a part of an encoded HAL document
+type Section int
+
+const (
+	// LinksSection is the "_links" object
+	LinksSection Section = iota
+	// TemplatesSection is the "_templates" object of HAL-FORMS
+	TemplatesSection
+	// StateSection are state properties in order of their declaration
+	StateSection
+	// EmbeddedSection is the "_embedded" object
+	EmbeddedSection
+)
+
+// DefaultSectionOrder puts links and templates first, followed by state
+// properties, embedded resources go last
+var DefaultSectionOrder = []Section{LinksSection, TemplatesSection, StateSection, EmbeddedSection}
+
+// DefaultLinkOrder lists relations written first, the rest is sorted
+var DefaultLinkOrder = []string{"self", "curies"}
+
+// Encoder writes HAL JSON documents with a deterministic key order to
+// an output stream. Sections of a document are written in a configurable
+// order, link relations listed in the link order are written first in that
+// order and the rest alphabetically, embedded resources are sorted by name.
+// The same order is used for embedded resources recursively
+type Encoder struct {
+	w         io.Writer
+	prefix    string
+	indent    string
+	sections  []Section
+	linkOrder []string
+}
+
+// NewEncoder returns a new encoder writing to w with the default order
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, sections: DefaultSectionOrder, linkOrder: DefaultLinkOrder}
+}
+
+// SetIndent sets the encoder to indent every element, see json.Indent
+func (e *Encoder) SetIndent(prefix, indent string) {
+	e.prefix, e.indent = prefix, indent
+}
+
+// SetSectionOrder sets the order sections of a document are written in,
+// sections which are not listed are left out
+func (e *Encoder) SetSectionOrder(sections ...Section) {
+	e.sections = sections
+}
+
+// SetLinkOrder sets relations which are written first in the given order
+func (e *Encoder) SetLinkOrder(rels ...string) {
+	e.linkOrder = rels
+}
+
+// Encode writes v as a HAL JSON document followed by a newline, v is
+// encoded the same way as by Marshal. Values which are not objects, such
+// as slices of resources, are written with their items in order
+func (e *Encoder) Encode(v interface{}) error {
+	buf := new(bytes.Buffer)
+	if resource, ok := v.(*Resource); ok {
+		if err := e.writeResource(buf, resource); err != nil {
+			return err
+		}
+	} else {
+		data, err := Marshal(v)
+		if err != nil {
+			return err
+		}
+		if err := e.writeValue(buf, data); err != nil {
+			return err
+		}
+	}
+	if e.prefix != "" || e.indent != "" {
+		indented := new(bytes.Buffer)
+		if err := json.Indent(indented, buf.Bytes(), e.prefix, e.indent); err != nil {
+			return err
+		}
+		buf = indented
+	}
+	buf.WriteByte('\n')
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+func (e *Encoder) writeResource(buf *bytes.Buffer, resource *Resource) error {
+	if resource == nil {
+		buf.WriteString("null")
+		return nil
+	}
+	buf.WriteByte('{')
+	first := true
+	key := func(name string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeJSONString(buf, name)
+		buf.WriteByte(':')
+	}
+	for _, section := range e.sections {
+		switch section {
+		case LinksSection:
+			if len(resource.Links) == 0 {
+				continue
+			}
+			key("_links")
+			buf.WriteByte('{')
+			for i, rel := range orderedKeys(resource.Links, e.linkOrder) {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				data, err := json.Marshal(resource.Links[rel])
+				if err != nil {
+					return err
+				}
+				writeJSONString(buf, rel)
+				buf.WriteByte(':')
+				buf.Write(data)
+			}
+			buf.WriteByte('}')
+		case TemplatesSection:
+			if len(resource.Templates) == 0 {
+				continue
+			}
+			data, err := json.Marshal(resource.Templates)
+			if err != nil {
+				return err
+			}
+			key("_templates")
+			buf.Write(data)
+		case StateSection:
+			for _, name := range resource.keys {
+				data, err := json.Marshal(resource.properties[name])
+				if err != nil {
+					return fmt.Errorf("Property \"%s\": %w", name, err)
+				}
+				key(name)
+				buf.Write(data)
+			}
+		case EmbeddedSection:
+			if len(resource.Embedded) == 0 {
+				continue
+			}
+			key("_embedded")
+			buf.WriteByte('{')
+			for i, name := range sortedKeys(resource.Embedded) {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				writeJSONString(buf, name)
+				buf.WriteByte(':')
+				if err := e.writeEmbedded(buf, resource.Embedded[name]); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte('}')
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func (e *Encoder) writeEmbedded(buf *bytes.Buffer, embedded Embedded) error {
+	switch typed := embedded.(type) {
+	case *Resource:
+		return e.writeResource(buf, typed)
+	case []*Resource:
+		buf.WriteByte('[')
+		for i, item := range typed {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := e.writeResource(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	data, err := Marshal(embedded)
+	if err != nil {
+		return err
+	}
+	return e.writeValue(buf, data)
+}
+
+// writeValue writes encoded data in order, objects are written as resources
+// and arrays item by item, other values are written as they are
+func (e *Encoder) writeValue(buf *bytes.Buffer, data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil
+	}
+	switch data[0] {
+	case '{':
+		return e.writeObject(buf, data)
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		buf.WriteByte('[')
+		for i, item := range items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := e.writeValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	}
+	buf.Write(data)
+	return nil
+}
+
+// writeObject writes an encoded resource in the order of its sections
+// without decoding links, templates and state properties
+func (e *Encoder) writeObject(buf *bytes.Buffer, data []byte) error {
+	keys, values, err := decodeObject(data)
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('{')
+	first := true
+	key := func(name string) {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		writeJSONString(buf, name)
+		buf.WriteByte(':')
+	}
+	for _, section := range e.sections {
+		switch section {
+		case LinksSection:
+			raw, ok := values["_links"]
+			if !ok {
+				continue
+			}
+			key("_links")
+			var links map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &links); err != nil || links == nil {
+				buf.Write(raw)
+				continue
+			}
+			buf.WriteByte('{')
+			for i, rel := range orderedKeys(links, e.linkOrder) {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				writeJSONString(buf, rel)
+				buf.WriteByte(':')
+				buf.Write(links[rel])
+			}
+			buf.WriteByte('}')
+		case TemplatesSection:
+			if raw, ok := values["_templates"]; ok {
+				key("_templates")
+				buf.Write(raw)
+			}
+		case StateSection:
+			for _, name := range keys {
+				if name != "_links" && name != "_templates" && name != "_embedded" {
+					key(name)
+					buf.Write(values[name])
+				}
+			}
+		case EmbeddedSection:
+			raw, ok := values["_embedded"]
+			if !ok {
+				continue
+			}
+			key("_embedded")
+			var embedded map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &embedded); err != nil || embedded == nil {
+				buf.Write(raw)
+				continue
+			}
+			buf.WriteByte('{')
+			for i, name := range sortedKeys(embedded) {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				writeJSONString(buf, name)
+				buf.WriteByte(':')
+				if err := e.writeValue(buf, embedded[name]); err != nil {
+					return fmt.Errorf("Embedded \"%s\": %w", name, err)
+				}
+			}
+			buf.WriteByte('}')
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// decodeObject splits an encoded object into its keys in order of
+// appearance and their raw values
+func decodeObject(data []byte) ([]string, map[string]json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+	var keys []string
+	values := make(map[string]json.RawMessage)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		name := token.(string)
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := values[name]; !ok {
+			keys = append(keys, name)
+		}
+		values[name] = raw
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
+
+// orderedKeys returns keys of m listed in order first followed
+// by the rest sorted
+func orderedKeys[V any](m map[string]V, order []string) []string {
+	keys := make([]string, 0, len(m))
+	listed := make(map[string]bool, len(order))
+	for _, key := range order {
+		if _, ok := m[key]; ok && !listed[key] {
+			keys = append(keys, key)
+			listed[key] = true
+		}
+	}
+	for _, key := range sortedKeys(m) {
+		if !listed[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s)
+	buf.Write(data)
+}
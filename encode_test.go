@@ -0,0 +1,223 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type embeddingCustomer struct {
+	Hal
+	Name   string            `json:"name"`
+	Orders []*embeddingOrder `json:"orders" hal:"embedded=orders"`
+}
+
+type embeddingOrder struct {
+	Hal
+	ID    uint  `json:"id"`
+	Items []Qux `json:"-" hal:"embedded=items"`
+}
+
+type pointerEmbedding struct {
+	*Hal
+	Name   string            `json:"name"`
+	Orders []*embeddingOrder `json:"-" hal:"embedded=orders"`
+}
+
+type invalidEmbedding struct {
+	Hal
+	Name string `json:"name" hal:"embedded=name"`
+}
+
+var expectedEmbeddingJSON = []byte(`{
+	"_links": {
+		"self": {
+			"href": "/v1/customers/1"
+		}
+	},
+	"name": "Bob",
+	"_embedded": {
+		"foobar": {
+			"id": 2,
+			"_embedded": {
+				"items": [
+					{
+						"id": 3,
+						"name": "Qux 3"
+					}
+				]
+			}
+		},
+		"orders": [
+			{
+				"id": 1,
+				"_embedded": {
+					"items": [
+						{
+							"id": 1,
+							"name": "Qux 1"
+						}
+					]
+				}
+			},
+			{
+				"id": 2
+			}
+		]
+	}
+}`)
+
+func TestMarshalEmbeddedTags(t *testing.T) {
+	customer := &embeddingCustomer{
+		Name: "Bob",
+		Orders: []*embeddingOrder{
+			{ID: 1, Items: []Qux{{ID: 1, Name: "Qux 1"}}},
+			{ID: 2},
+		},
+	}
+	customer.SetLink("self", "/v1/customers/1", "")
+	customer.SetEmbedded("foobar", &embeddingOrder{ID: 2, Items: []Qux{{ID: 3, Name: "Qux 3"}}})
+
+	// Assert JSON after marshalling is as expected
+	expected := bytes.NewBuffer([]byte{})
+	err := json.Compact(expected, expectedEmbeddingJSON)
+	if err != nil {
+		log.Fatal(err)
+	}
+	actual, err := Marshal(customer)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), string(actual))
+
+	// Values and slices are encoded the same way as pointers
+	actual, err = Marshal(*customer)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), string(actual))
+	actual, err = Marshal([]*embeddingCustomer{customer})
+	assert.NoError(t, err)
+	assert.Equal(t, "["+expected.String()+"]", string(actual))
+
+	// Test decoding back into the tagged fields
+	decoded := new(embeddingCustomer)
+	err = Unmarshal(actual[1:len(actual)-1], decoded, EmbeddedTypes{"foobar": (*embeddingOrder)(nil)})
+	assert.NoError(t, err)
+	assert.Equal(t, customer, decoded)
+
+	// A single embedded resource is decoded into a slice of one item
+	decoded = new(embeddingCustomer)
+	err = Unmarshal([]byte(`{"_embedded": {"orders": {"id": 1}}, "name": "Bob"}`), decoded, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &embeddingCustomer{Name: "Bob", Orders: []*embeddingOrder{{ID: 1}}}, decoded)
+
+	// Nil values are left out
+	actual, err = Marshal(&embeddingCustomer{Name: "Bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Bob"}`, string(actual))
+
+	// Test a nil embedded *Hal
+	actual, err = Marshal(&pointerEmbedding{Name: "Bob"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Bob"}`, string(actual))
+	actual, err = Marshal(pointerEmbedding{Name: "Bob", Orders: []*embeddingOrder{{ID: 1}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Bob","_embedded":{"orders":[{"id":1}]}}`, string(actual))
+}
+
+func TestMarshalEmbeddedTagErrors(t *testing.T) {
+	_, err := Marshal(&invalidEmbedding{Name: "Bob"})
+	assert.True(t, errors.Is(err, ErrInvalidTag))
+	assert.EqualError(t, err, "Invalid hal tag of jsonhal.invalidEmbedding.Name: Embedded \"name\" cannot be string")
+
+	err = Unmarshal([]byte(`{"name": "Bob"}`), new(invalidEmbedding), nil)
+	assert.True(t, errors.Is(err, ErrInvalidTag))
+
+	err = Unmarshal([]byte(`{"_embedded": {"items": {"id": "1"}}}`), new(embeddingOrder), nil)
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+	assert.Contains(t, err.Error(), "Embedded \"items\": json: cannot unmarshal string")
+}
+
+func TestEncoder(t *testing.T) {
+	customer := &embeddingCustomer{
+		Name:   "Bob",
+		Orders: []*embeddingOrder{{ID: 1}},
+	}
+	customer.SetLink("self", "/v1/customers/1", "")
+	customer.SetLink("next", "/v1/customers/2", "")
+	customer.SetLink("alternate", "/v1/customers/1.xml", "")
+	customer.Orders[0].SetLink("self", "/v1/orders/1", "")
+	customer.Orders[0].SetLink("customer", "/v1/customers/1", "")
+	customer.SetTemplate("default", NewForm("PUT", ""))
+
+	// Test the default order
+	buf := new(bytes.Buffer)
+	assert.NoError(t, NewEncoder(buf).Encode(customer))
+	assert.Equal(
+		t,
+		`{"_links":{"self":{"href":"/v1/customers/1"},"alternate":{"href":"/v1/customers/1.xml"},"next":{"href":"/v1/customers/2"}},`+
+			`"_templates":{"default":{"method":"PUT"}},"name":"Bob",`+
+			`"_embedded":{"orders":[{"_links":{"self":{"href":"/v1/orders/1"},"customer":{"href":"/v1/customers/1"}},"id":1}]}}`+"\n",
+		buf.String(),
+	)
+
+	// Test a custom order with indentation
+	buf.Reset()
+	encoder := NewEncoder(buf)
+	encoder.SetSectionOrder(StateSection, EmbeddedSection, LinksSection)
+	encoder.SetLinkOrder("next", "self")
+	encoder.SetIndent("", "  ")
+	assert.NoError(t, encoder.Encode(customer))
+	assert.Equal(t, `{
+  "name": "Bob",
+  "_embedded": {
+    "orders": [
+      {
+        "id": 1,
+        "_links": {
+          "self": {
+            "href": "/v1/orders/1"
+          },
+          "customer": {
+            "href": "/v1/customers/1"
+          }
+        }
+      }
+    ]
+  },
+  "_links": {
+    "next": {
+      "href": "/v1/customers/2"
+    },
+    "self": {
+      "href": "/v1/customers/1"
+    },
+    "alternate": {
+      "href": "/v1/customers/1.xml"
+    }
+  }
+}
+`, buf.String())
+
+	// Test the output is stable
+	first := new(bytes.Buffer)
+	second := new(bytes.Buffer)
+	assert.NoError(t, NewEncoder(first).Encode(customer))
+	assert.NoError(t, NewEncoder(second).Encode(customer))
+	assert.Equal(t, first.String(), second.String())
+
+	// Test errors
+	assert.Error(t, NewEncoder(buf).Encode(&invalidEmbedding{}))
+
+	// Test values which are not resources are written as they are
+	buf.Reset()
+	assert.NoError(t, NewEncoder(buf).Encode([]string{"foo"}))
+	assert.Equal(t, "[\"foo\"]\n", buf.String())
+	tagged := &Resource{}
+	tagged.SetLink("self", "/v1/tagged", "")
+	tagged.SetEmbedded("tags", []string{"x"})
+	buf.Reset()
+	assert.NoError(t, NewEncoder(buf).Encode(tagged))
+	assert.Equal(t, `{"_links":{"self":{"href":"/v1/tagged"}},"_embedded":{"tags":["x"]}}`+"\n", buf.String())
+}
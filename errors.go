@@ -0,0 +1,115 @@
+package jsonhal
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrLinkNotFound is matched by errors returned when a link is not found
+	ErrLinkNotFound = errors.New("link not found")
+	// ErrEmbeddedNotFound is matched by errors returned when an embedded
+	// resource is not found
+	ErrEmbeddedNotFound = errors.New("embedded not found")
+	// ErrCurieNotFound is matched by errors returned when a CURIE is not found
+	ErrCurieNotFound = errors.New("CURIE not found")
+	// ErrPropertyNotFound is matched by errors returned when a state property
+	// of a generic resource or a property of a HAL-FORMS template is not found
+	ErrPropertyNotFound = errors.New("property not found")
+	// ErrTemplateNotFound is matched by errors returned when a HAL-FORMS
+	// template is not found
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrInvalidTag is matched by errors returned when a hal struct tag
+	// is malformed
+	ErrInvalidTag = errors.New("invalid tag")
+	// ErrRouteNotFound is matched by errors returned when a named route
+	// is not found
+	ErrRouteNotFound = errors.New("route not found")
+	// ErrMissingParameter is matched by errors returned when a parameter
+	// of a route or a field referred to by a tagged link is missing
+	ErrMissingParameter = errors.New("missing parameter")
+	// ErrTypeMismatch is matched by errors returned when a value
+	// is not of the requested type
+	ErrTypeMismatch = errors.New("type mismatch")
+	// ErrInvalidDocument is matched by errors returned when a HAL document
+	// is malformed or violates the specification
+	ErrInvalidDocument = errors.New("invalid document")
+	// ErrInvalidCursor is matched by errors returned when a cursor token
+	// is malformed or its signature is invalid
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidPayload is matched by errors returned when a payload
+	// submitted to a HAL-FORMS template is invalid
+	ErrInvalidPayload = errors.New("invalid payload")
+	// ErrInvalidForm is matched by errors returned when a HAL-FORMS
+	// template itself is invalid, such as a property with a malformed regex
+	ErrInvalidForm = errors.New("invalid form")
+)
+
+// NotFoundError is returned by lookups of links, embedded resources,
+// CURIEs, templates, routes and properties which do not exist
+type NotFoundError struct {
+	Kind error  // ErrLinkNotFound, ErrEmbeddedNotFound, ErrCurieNotFound etc
+	Name string // name of the link, embedded resource, CURIE, template, route or property
+}
+
+func (e *NotFoundError) Error() string {
+	switch e.Kind {
+	case ErrLinkNotFound:
+		return fmt.Sprintf("Link \"%s\" not found", e.Name)
+	case ErrEmbeddedNotFound:
+		return fmt.Sprintf("Embedded \"%s\" not found", e.Name)
+	case ErrCurieNotFound:
+		return fmt.Sprintf("CURIE \"%s\" not found", e.Name)
+	case ErrTemplateNotFound:
+		return fmt.Sprintf("Template \"%s\" not found", e.Name)
+	case ErrRouteNotFound:
+		return fmt.Sprintf("Route \"%s\" not found", e.Name)
+	case ErrPropertyNotFound:
+		return fmt.Sprintf("Property \"%s\" not found", e.Name)
+	}
+	return fmt.Sprintf("\"%s\" not found", e.Name)
+}
+
+// Unwrap returns the kind of the error so it can be matched with errors.Is
+func (e *NotFoundError) Unwrap() error {
+	return e.Kind
+}
+
+// InvalidDocumentError is returned when a HAL document cannot be decoded
+// or violates the specification
+type InvalidDocumentError struct {
+	Err error
+}
+
+func (e *InvalidDocumentError) Error() string {
+	return e.Err.Error()
+}
+
+// Is reports whether target is ErrInvalidDocument
+func (e *InvalidDocumentError) Is(target error) bool {
+	return target == ErrInvalidDocument
+}
+
+// Unwrap returns the underlying error
+func (e *InvalidDocumentError) Unwrap() error {
+	return e.Err
+}
+
+func linkNotFound(name string) error {
+	return &NotFoundError{Kind: ErrLinkNotFound, Name: name}
+}
+
+func embeddedNotFound(name string) error {
+	return &NotFoundError{Kind: ErrEmbeddedNotFound, Name: name}
+}
+
+func invalidDocument(err error) error {
+	if err == nil {
+		return nil
+	}
+	var docErr *InvalidDocumentError
+	if errors.As(err, &docErr) {
+		return err
+	}
+	return &InvalidDocumentError{Err: err}
+}
@@ -0,0 +1,73 @@
+package jsonhal
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrors(t *testing.T) {
+	var (
+		notFoundErr *NotFoundError
+		docErr      *InvalidDocumentError
+		typeErr     *EmbeddedTypeError
+		jsonErr     *json.UnmarshalTypeError
+		err         error
+	)
+
+	helloWorld := new(HelloWorld)
+	helloWorld.SetEmbedded("foobar", Embedded(&Foobar{ID: 1}))
+
+	// Test lookup failures
+	_, err = helloWorld.GetLink("self")
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+	assert.False(t, errors.Is(err, ErrEmbeddedNotFound))
+	if assert.True(t, errors.As(err, &notFoundErr)) {
+		assert.Equal(t, "self", notFoundErr.Name)
+	}
+
+	_, err = helloWorld.GetLinks("self")
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+
+	_, err = helloWorld.GetEmbedded("bogus")
+	assert.True(t, errors.Is(err, ErrEmbeddedNotFound))
+	assert.False(t, errors.Is(err, ErrLinkNotFound))
+
+	_, err = GetEmbeddedAs[*Foobar](helloWorld, "bogus")
+	assert.True(t, errors.Is(err, ErrEmbeddedNotFound))
+
+	_, err = helloWorld.GetCurie("acme")
+	assert.True(t, errors.Is(err, ErrCurieNotFound))
+
+	_, err = helloWorld.ExpandRel("acme:widgets")
+	assert.True(t, errors.Is(err, ErrCurieNotFound))
+	assert.EqualError(t, err, "CURIE \"acme\" not found")
+
+	// Test type mismatches
+	_, err = GetEmbeddedAs[*Qux](helloWorld, "foobar")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+	assert.True(t, errors.As(err, &typeErr))
+
+	_, err = GetEmbeddedSlice[*Qux](helloWorld, "foobar")
+	assert.True(t, errors.Is(err, ErrTypeMismatch))
+	assert.False(t, errors.Is(err, ErrEmbeddedNotFound))
+
+	// Test invalid documents
+	helloWorld.SetLink("acme:widgets", "/v1/widgets", "")
+	err = helloWorld.ValidateCuries()
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+
+	err = Unmarshal([]byte(`{"_links": []}`), new(HelloWorld), nil)
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+	assert.True(t, errors.As(err, &docErr))
+	assert.True(t, errors.As(err, &jsonErr))
+
+	err = Unmarshal(nestedEmbeddedJSON, new(HelloWorld), EmbeddedTypes{"foobar": []*Foobar(nil)})
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+	assert.True(t, errors.As(err, &jsonErr))
+
+	err = Unmarshal(nestedEmbeddedJSON, new(HelloWorld), EmbeddedTypes{"foobar": nil})
+	assert.False(t, errors.Is(err, ErrInvalidDocument))
+}
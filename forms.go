@@ -0,0 +1,355 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Form is a HAL-FORMS template in "_templates" object describing an action
+// which can be performed on a resource.
+// HAL-FORMS specification: https://rwcbook.github.io/hal-forms/
+type Form struct {
+	Title       string          `json:"title,omitempty"`
+	Method      string          `json:"method"`
+	ContentType string          `json:"contentType,omitempty"`
+	Target      string          `json:"target,omitempty"`
+	Properties  []*FormProperty `json:"properties,omitempty"`
+}
+
+// FormProperty describes a single property of a form
+type FormProperty struct {
+	Name        string       `json:"name"`
+	Prompt      string       `json:"prompt,omitempty"`
+	ReadOnly    bool         `json:"readOnly,omitempty"`
+	Regex       string       `json:"regex,omitempty"`
+	Required    bool         `json:"required,omitempty"`
+	Templated   bool         `json:"templated,omitempty"`
+	Value       string       `json:"value,omitempty"`
+	Type        string       `json:"type,omitempty"`
+	Placeholder string       `json:"placeholder,omitempty"`
+	Min         *float64     `json:"min,omitempty"`
+	Max         *float64     `json:"max,omitempty"`
+	MinLength   *int         `json:"minLength,omitempty"`
+	MaxLength   *int         `json:"maxLength,omitempty"`
+	Step        *float64     `json:"step,omitempty"`
+	Cols        int          `json:"cols,omitempty"`
+	Rows        int          `json:"rows,omitempty"`
+	Options     *FormOptions `json:"options,omitempty"`
+}
+
+// FormOptions lists values a property can take, either inline
+// or provided by a linked resource
+type FormOptions struct {
+	Inline         []Choice `json:"inline,omitempty"`
+	Link           *Link    `json:"link,omitempty"`
+	MaxItems       *int     `json:"maxItems,omitempty"`
+	MinItems       *int     `json:"minItems,omitempty"`
+	PromptField    string   `json:"promptField,omitempty"`
+	ValueField     string   `json:"valueField,omitempty"`
+	SelectedValues []string `json:"selectedValues,omitempty"`
+}
+
+// Choice is a single inline option of a property
+type Choice struct {
+	Prompt string `json:"prompt"`
+	Value  string `json:"value"`
+}
+
+// UnmarshalJSON decodes a choice from an object or a plain string,
+// which is used as both prompt and value
+func (c *Choice) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		c.Prompt, c.Value = value, value
+		return nil
+	}
+	type choice Choice
+	return json.Unmarshal(data, (*choice)(c))
+}
+
+// FormPropertyOption sets an optional attribute of a form property
+type FormPropertyOption func(*FormProperty)
+
+// Required marks the property as required
+func Required() FormPropertyOption {
+	return func(p *FormProperty) { p.Required = true }
+}
+
+// ReadOnly marks the property as read only
+func ReadOnly() FormPropertyOption {
+	return func(p *FormProperty) { p.ReadOnly = true }
+}
+
+// Prompt sets a human readable prompt of the property
+func Prompt(prompt string) FormPropertyOption {
+	return func(p *FormProperty) { p.Prompt = prompt }
+}
+
+// Regex sets a regular expression the property value must match
+func Regex(regex string) FormPropertyOption {
+	return func(p *FormProperty) { p.Regex = regex }
+}
+
+// DefaultValue sets the property value
+func DefaultValue(value string) FormPropertyOption {
+	return func(p *FormProperty) { p.Value = value }
+}
+
+// InputType sets a hint about the property input type (text, number, email etc)
+func InputType(inputType string) FormPropertyOption {
+	return func(p *FormProperty) { p.Type = inputType }
+}
+
+// Placeholder sets a hint shown in an empty property input
+func Placeholder(placeholder string) FormPropertyOption {
+	return func(p *FormProperty) { p.Placeholder = placeholder }
+}
+
+// Range sets the minimum and maximum numeric value of the property
+func Range(min, max float64) FormPropertyOption {
+	return func(p *FormProperty) { p.Min, p.Max = &min, &max }
+}
+
+// Length sets the minimum and maximum length of the property value
+func Length(min, max int) FormPropertyOption {
+	return func(p *FormProperty) { p.MinLength, p.MaxLength = &min, &max }
+}
+
+// InlineOptions restricts the property value to one of choices
+func InlineOptions(choices ...Choice) FormPropertyOption {
+	return func(p *FormProperty) {
+		if p.Options == nil {
+			p.Options = new(FormOptions)
+		}
+		p.Options.Inline = choices
+	}
+}
+
+// NewForm returns a new form submitted with method to target,
+// an empty target means the resource self link
+func NewForm(method, target string) *Form {
+	return &Form{Method: method, Target: target}
+}
+
+// AddProperty adds a property to the form and returns the form
+func (f *Form) AddProperty(name string, options ...FormPropertyOption) *Form {
+	property := &FormProperty{Name: name}
+	for _, option := range options {
+		option(property)
+	}
+	f.Properties = append(f.Properties, property)
+	return f
+}
+
+// GetProperty returns a form property by name or error matching
+// ErrPropertyNotFound
+func (f *Form) GetProperty(name string) (*FormProperty, error) {
+	for _, property := range f.Properties {
+		if property.Name == name {
+			return property, nil
+		}
+	}
+	return nil, &NotFoundError{Kind: ErrPropertyNotFound, Name: name}
+}
+
+// SetTemplate sets a form in "_templates" object, the form
+// of the default action should be named "default"
+func (h *Hal) SetTemplate(name string, form *Form) {
+	if h.Templates == nil {
+		h.Templates = make(map[string]*Form, 0)
+	}
+	h.Templates[name] = form
+}
+
+// GetTemplate returns a form by name or error matching ErrTemplateNotFound
+func (h *Hal) GetTemplate(name string) (*Form, error) {
+	form, ok := h.Templates[name]
+	if !ok {
+		return nil, &NotFoundError{Kind: ErrTemplateNotFound, Name: name}
+	}
+	return form, nil
+}
+
+// DeleteTemplate removes a form named name if it is found
+func (h *Hal) DeleteTemplate(name string) {
+	if h.Templates != nil {
+		delete(h.Templates, name)
+	}
+}
+
+// PropertyError describes why a single property of a payload is invalid
+type PropertyError struct {
+	Name    string
+	Message string
+}
+
+// ValidationError lists all invalid properties of a payload
+type ValidationError struct {
+	Errors []PropertyError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, propertyErr := range e.Errors {
+		messages[i] = fmt.Sprintf("\"%s\" %s", propertyErr.Name, propertyErr.Message)
+	}
+	return "Invalid payload: " + strings.Join(messages, "; ")
+}
+
+// Is reports whether target is ErrInvalidPayload
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidPayload
+}
+
+// ValidateJSON decodes a JSON payload and validates it, see Validate
+func (f *Form) ValidateJSON(data []byte) error {
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPayload, err)
+	}
+	return f.Validate(payload)
+}
+
+// Validate checks a submitted payload against the form properties and
+// returns *ValidationError listing all invalid properties, if any. Values
+// of properties with options can be arrays when multiple options are selected.
+// A form with an invalid regex results in an error matching ErrInvalidForm
+// rather than ErrInvalidPayload as it is not the payload which is invalid
+func (f *Form) Validate(payload map[string]interface{}) error {
+	compiled := make([]*regexp.Regexp, len(f.Properties))
+	for i, property := range f.Properties {
+		if property.Regex == "" {
+			continue
+		}
+		re, err := compileRegex(property.Regex)
+		if err != nil {
+			return fmt.Errorf("%w: property \"%s\": %w", ErrInvalidForm, property.Name, err)
+		}
+		compiled[i] = re
+	}
+
+	validationErr := new(ValidationError)
+	for i, property := range f.Properties {
+		if message := property.validate(payload, compiled[i]); message != "" {
+			validationErr.Errors = append(validationErr.Errors, PropertyError{
+				Name:    property.Name,
+				Message: message,
+			})
+		}
+	}
+	if len(validationErr.Errors) > 0 {
+		return validationErr
+	}
+	return nil
+}
+
+// regexes caches compiled property regexes by their source as forms
+// are usually validated many times with the same few regexes
+var regexes sync.Map
+
+// compileRegex returns regex compiled to match whole values, compiled
+// regexes are cached and shared as they are safe for concurrent use
+func compileRegex(regex string) (*regexp.Regexp, error) {
+	if re, ok := regexes.Load(regex); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile("^(?:" + regex + ")$")
+	if err != nil {
+		return nil, err
+	}
+	regexes.Store(regex, re)
+	return re, nil
+}
+
+// validate returns a message describing why the property value in
+// a payload is invalid or an empty string, re is the compiled Regex
+func (p *FormProperty) validate(payload map[string]interface{}, re *regexp.Regexp) string {
+	value, ok := payload[p.Name]
+	if !ok || value == nil || value == "" {
+		if p.Required {
+			return "is required"
+		}
+		return ""
+	}
+
+	values, isArray := value.([]interface{})
+	if !isArray {
+		values = []interface{}{value}
+	}
+	if p.Options != nil {
+		if p.Options.MinItems != nil && len(values) < *p.Options.MinItems {
+			return fmt.Sprintf("must have at least %d items", *p.Options.MinItems)
+		}
+		if p.Options.MaxItems != nil && len(values) > *p.Options.MaxItems {
+			return fmt.Sprintf("must have at most %d items", *p.Options.MaxItems)
+		}
+	}
+	if isArray && p.Options == nil {
+		return "must not be an array"
+	}
+
+	for _, item := range values {
+		if message := p.validateValue(item, re); message != "" {
+			return message
+		}
+	}
+	return ""
+}
+
+func (p *FormProperty) validateValue(value interface{}, re *regexp.Regexp) string {
+	s, err := scalarString(reflect.ValueOf(value))
+	if err != nil {
+		return "must be a scalar value"
+	}
+	if p.ReadOnly && s != p.Value {
+		return "is read only"
+	}
+	if re != nil && !re.MatchString(s) {
+		return fmt.Sprintf("must match %s", p.Regex)
+	}
+	if p.MinLength != nil && utf8.RuneCountInString(s) < *p.MinLength {
+		return fmt.Sprintf("must be at least %d characters long", *p.MinLength)
+	}
+	if p.MaxLength != nil && utf8.RuneCountInString(s) > *p.MaxLength {
+		return fmt.Sprintf("must be at most %d characters long", *p.MaxLength)
+	}
+	if p.Min != nil || p.Max != nil {
+		number, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(number) {
+			return "must be a number"
+		}
+		if p.Min != nil && number < *p.Min {
+			return fmt.Sprintf("must be at least %s", formatNumber(*p.Min))
+		}
+		if p.Max != nil && number > *p.Max {
+			return fmt.Sprintf("must be at most %s", formatNumber(*p.Max))
+		}
+	}
+	if p.Options != nil && len(p.Options.Inline) > 0 {
+		for _, choice := range p.Options.Inline {
+			if choice.Value == s {
+				return ""
+			}
+		}
+		return "must be one of the options"
+	}
+	return ""
+}
+
+func formatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 64)
+}
@@ -0,0 +1,219 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var expectedFormsJSON = []byte(`{
+	"_links": {
+		"self": {
+			"href": "/v1/orders/1"
+		}
+	},
+	"_templates": {
+		"default": {
+			"title": "Update order",
+			"method": "PUT",
+			"contentType": "application/json",
+			"properties": [
+				{
+					"name": "id",
+					"readOnly": true,
+					"value": "1"
+				},
+				{
+					"name": "sku",
+					"prompt": "SKU",
+					"regex": "[a-z]{3}",
+					"required": true
+				},
+				{
+					"name": "quantity",
+					"type": "number",
+					"min": 1,
+					"max": 10
+				},
+				{
+					"name": "note",
+					"placeholder": "Leave at the door",
+					"maxLength": 5
+				},
+				{
+					"name": "color",
+					"options": {
+						"inline": [
+							{
+								"prompt": "Red",
+								"value": "red"
+							},
+							{
+								"prompt": "Blue",
+								"value": "blue"
+							}
+						],
+						"maxItems": 2
+					}
+				}
+			]
+		}
+	},
+	"id": 1,
+	"name": "Order 1"
+}`)
+
+func TestForms(t *testing.T) {
+	order := &HelloWorld{ID: 1, Name: "Order 1"}
+	order.SetLink("self", "/v1/orders/1", "")
+
+	form := NewForm("PUT", "").
+		AddProperty("id", ReadOnly(), DefaultValue("1")).
+		AddProperty("sku", Prompt("SKU"), Regex("[a-z]{3}"), Required()).
+		AddProperty("quantity", InputType("number"), Range(1, 10)).
+		AddProperty("note", Placeholder("Leave at the door"), func(p *FormProperty) {
+			maxLength := 5
+			p.MaxLength = &maxLength
+		}).
+		AddProperty("color", InlineOptions(Choice{"Red", "red"}, Choice{"Blue", "blue"}))
+	form.Title = "Update order"
+	form.ContentType = "application/json"
+	maxItems := 2
+	color, err := form.GetProperty("color")
+	assert.NoError(t, err)
+	color.Options.MaxItems = &maxItems
+	order.SetTemplate("default", form)
+
+	// Assert JSON after marshalling is as expected
+	expected := bytes.NewBuffer([]byte{})
+	err = json.Compact(expected, expectedFormsJSON)
+	if err != nil {
+		log.Fatal(err)
+	}
+	actual, err := json.Marshal(order)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, expected.String(), string(actual))
+
+	// Test decoding into structs and generic resources
+	decoded := new(HelloWorld)
+	assert.NoError(t, json.Unmarshal(actual, decoded))
+	decodedForm, err := decoded.GetTemplate("default")
+	assert.NoError(t, err)
+	assert.Equal(t, form, decodedForm)
+
+	resource := NewResource()
+	assert.NoError(t, json.Unmarshal(actual, resource))
+	_, err = resource.GetTemplate("default")
+	assert.NoError(t, err)
+	reencoded, err := json.Marshal(resource)
+	assert.NoError(t, err)
+	assert.Equal(t, string(actual), string(reencoded))
+
+	// Test a missing property
+	_, err = form.GetProperty("bogus")
+	assert.True(t, errors.Is(err, ErrPropertyNotFound))
+	assert.EqualError(t, err, "Property \"bogus\" not found")
+
+	// Test missing and deleted templates
+	_, err = order.GetTemplate("bogus")
+	assert.True(t, errors.Is(err, ErrTemplateNotFound))
+	assert.EqualError(t, err, "Template \"bogus\" not found")
+	order.DeleteTemplate("default")
+	_, err = order.GetTemplate("default")
+	assert.True(t, errors.Is(err, ErrTemplateNotFound))
+}
+
+func TestChoiceUnmarshal(t *testing.T) {
+	var options FormOptions
+	err := json.Unmarshal([]byte(`{"inline": ["red", {"prompt": "Blue", "value": "blue"}]}`), &options)
+	assert.NoError(t, err)
+	assert.Equal(t, []Choice{{"red", "red"}, {"Blue", "blue"}}, options.Inline)
+
+	err = json.Unmarshal([]byte(`{"inline": [1]}`), &options)
+	assert.Error(t, err)
+}
+
+func TestFormValidate(t *testing.T) {
+	one := 1
+	form := NewForm("POST", "/v1/orders").
+		AddProperty("id", ReadOnly(), DefaultValue("1")).
+		AddProperty("sku", Regex("[a-z]{3}"), Required()).
+		AddProperty("quantity", Range(1, 10)).
+		AddProperty("note", Length(2, 5)).
+		AddProperty("color", InlineOptions(Choice{"Red", "red"}, Choice{"Blue", "blue"})).
+		AddProperty("size", InlineOptions(Choice{"S", "s"}, Choice{"M", "m"}), func(p *FormProperty) {
+			p.Options.MinItems = &one
+			p.Options.MaxItems = &one
+		})
+
+	// Test a valid payload
+	assert.NoError(t, form.ValidateJSON([]byte(`{
+		"id": "1",
+		"sku": "abc",
+		"quantity": 10,
+		"note": "hello",
+		"color": ["red", "blue"],
+		"size": "m",
+		"unknown": true
+	}`)))
+	assert.NoError(t, form.Validate(map[string]interface{}{"sku": "abc", "quantity": 1.5, "note": nil}))
+
+	// Test invalid payloads
+	testCases := []struct {
+		payload  string
+		expected string
+	}{
+		{`{}`, `Invalid payload: "sku" is required`},
+		{`{"sku": ""}`, `Invalid payload: "sku" is required`},
+		{`{"sku": "abcd", "id": 2}`, `Invalid payload: "id" is read only; "sku" must match [a-z]{3}`},
+		{`{"sku": "abc", "quantity": 0}`, `Invalid payload: "quantity" must be at least 1`},
+		{`{"sku": "abc", "quantity": 11}`, `Invalid payload: "quantity" must be at most 10`},
+		{`{"sku": "abc", "quantity": "many"}`, `Invalid payload: "quantity" must be a number`},
+		{`{"sku": "abc", "note": "a"}`, `Invalid payload: "note" must be at least 2 characters long`},
+		{`{"sku": "abc", "note": "hello world"}`, `Invalid payload: "note" must be at most 5 characters long`},
+		{`{"sku": "abc", "note": ["hi"]}`, `Invalid payload: "note" must not be an array`},
+		{`{"sku": "abc", "note": {"text": "hi"}}`, `Invalid payload: "note" must be a scalar value`},
+		{`{"sku": "abc", "color": "green"}`, `Invalid payload: "color" must be one of the options`},
+		{`{"sku": "abc", "size": []}`, `Invalid payload: "size" must have at least 1 items`},
+		{`{"sku": "abc", "size": ["s", "m"]}`, `Invalid payload: "size" must have at most 1 items`},
+	}
+	for _, testCase := range testCases {
+		err := form.ValidateJSON([]byte(testCase.payload))
+		assert.True(t, errors.Is(err, ErrInvalidPayload), testCase.payload)
+		assert.EqualError(t, err, testCase.expected, testCase.payload)
+	}
+
+	var validationErr *ValidationError
+	err := form.ValidateJSON([]byte(`{"sku": "abcd", "id": 2}`))
+	if assert.True(t, errors.As(err, &validationErr)) {
+		assert.Equal(t, []PropertyError{
+			{Name: "id", Message: "is read only"},
+			{Name: "sku", Message: "must match [a-z]{3}"},
+		}, validationErr.Errors)
+	}
+
+	// Test a malformed payload
+	err = form.ValidateJSON([]byte(`{`))
+	assert.True(t, errors.Is(err, ErrInvalidPayload))
+	assert.EqualError(t, err, "invalid payload: unexpected EOF")
+
+	// Test a form with a malformed regex
+	err = NewForm("POST", "").AddProperty("sku", Regex("[a-z")).Validate(map[string]interface{}{"sku": "abc"})
+	assert.True(t, errors.Is(err, ErrInvalidForm))
+	assert.False(t, errors.Is(err, ErrInvalidPayload))
+	assert.Contains(t, err.Error(), "invalid form: property \"sku\": error parsing regexp")
+
+	// Test compiled regexes are reused
+	first, err := compileRegex("[a-z]{3}")
+	assert.NoError(t, err)
+	second, err := compileRegex("[a-z]{3}")
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.False(t, first.MatchString("abcd"))
+}
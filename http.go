@@ -0,0 +1,168 @@
+package jsonhal
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ContentType is the media type of HAL JSON responses
+const ContentType = "application/hal+json"
+
+// HTTPError is an error with the HTTP status code to respond with
+type HTTPError struct {
+	Status int
+	Err    error
+}
+
+// NewHTTPError returns a new error responded with status code
+func NewHTTPError(status int, err error) *HTTPError {
+	return &HTTPError{Status: status, Err: err}
+}
+
+func (e *HTTPError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorResource is the resource written in responses to errors
+type ErrorResource struct {
+	Hal
+	Message string `json:"message"`
+}
+
+// WriteResource writes v, usually a struct embedding Hal, as a HAL JSON
+// response with status code. Keys are written in the default order of
+// Encoder and the response is indented when the request has a "pretty"
+// query parameter. When v cannot be encoded or its compact relations use
+// CURIEs which are not registered, see ValidateCuries, an internal server
+// error is written instead and the error is returned.
+// Options can add headers derived from the resource, see WithLinkHeader
+func WriteResource(w http.ResponseWriter, r *http.Request, status int, v interface{}, options ...WriteOption) error {
+	resolved := resolveForRequest(r, v)
+	err := validateCuries(resolved)
+	var data []byte
+	if err == nil {
+		data, err = encodeResource(r, resolved)
+	}
+	if err != nil {
+		WriteError(w, r, err)
+		return err
+	}
+	newWriteOptions(options).apply(w, resolved)
+	writeResponse(w, r, status, ContentType, data)
+	return nil
+}
+
+// WriteError writes an error as a HAL JSON response. The status code is
+// taken from *HTTPError and defaults to 500, messages of server errors
+// are not exposed to clients
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		status = httpErr.Status
+	}
+	resource := &ErrorResource{Message: http.StatusText(status)}
+	if status < http.StatusInternalServerError {
+		resource.Message = err.Error()
+	}
+	data, encodeErr := encodeResource(r, resource)
+	if encodeErr != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	writeResponse(w, r, status, ContentType, data)
+}
+
+// HandlerFunc is an adapter to use functions returning a status code and
+// a resource as HTTP handlers. Returned errors are written by WriteError
+type HandlerFunc[T any] func(r *http.Request) (int, T, error)
+
+// ServeHTTP calls f and writes the resource or error it returns
+func (f HandlerFunc[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.serve(w, r, nil)
+}
+
+// With returns a handler writing resources with options, see WriteResource
+func (f HandlerFunc[T]) With(options ...WriteOption) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.serve(w, r, options)
+	})
+}
+
+func (f HandlerFunc[T]) serve(w http.ResponseWriter, r *http.Request, options []WriteOption) {
+	status, v, err := f(r)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	WriteResource(w, r, status, v, options...)
+}
+
+// validateCuries checks compact relations of v, if it is a resource
+// embedding Hal, use registered CURIEs
+func validateCuries(v interface{}) error {
+	if h := halOf(v); h != nil {
+		return h.ValidateCuries()
+	}
+	return nil
+}
+
+// encodeResource encodes v in the default order of Encoder, the trailing
+// newline is left out as writeResponse adds it. Hrefs of v should already
+// be resolved, see resolveForRequest
+func encodeResource(r *http.Request, v interface{}) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	encoder := NewEncoder(buf)
+	if pretty(r) {
+		encoder.SetIndent("", "\t")
+	}
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// pretty reports whether the request asks for an indented response
+func pretty(r *http.Request) bool {
+	if r == nil || r.URL == nil {
+		return false
+	}
+	values, ok := r.URL.Query()["pretty"]
+	if !ok {
+		return false
+	}
+	if len(values) == 0 || values[0] == "" {
+		return true
+	}
+	value, err := strconv.ParseBool(values[0])
+	return err == nil && value
+}
+
+// writeResponse writes data followed by a newline, responses to HEAD
+// requests and responses with 1xx, 204 and 304 status codes have no body
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, contentType string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	if !hasBody(r, status) {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)+1))
+	w.WriteHeader(status)
+	w.Write(data)
+	w.Write([]byte("\n"))
+}
+
+// hasBody reports whether a response with status to r can have a body
+func hasBody(r *http.Request, status int) bool {
+	if r != nil && r.Method == http.MethodHead {
+		return false
+	}
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,153 @@
+package jsonhal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteResource(t *testing.T) {
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+
+	// Test a compact response
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/v1/hello/world/1", nil)
+	assert.NoError(t, WriteResource(w, r, http.StatusCreated, helloWorld))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/hal+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"_links":{"self":{"href":"/v1/hello/world/1"}},"id":1,"name":"Hello World"}`+"\n", w.Body.String())
+
+	// Test a pretty printed response
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/v1/hello/world/1?pretty", nil)
+	assert.NoError(t, WriteResource(w, r, http.StatusOK, helloWorld))
+	assert.Equal(t, string(expectedJSON2)+"\n", w.Body.String())
+	assert.Equal(t, strconv.Itoa(w.Body.Len()), w.Header().Get("Content-Length"))
+
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/v1/hello/world/1?pretty=false", nil)
+	assert.NoError(t, WriteResource(w, r, http.StatusOK, helloWorld))
+	assert.NotContains(t, w.Body.String(), "\t")
+
+	// Test responses without a body
+	for _, testCase := range []struct {
+		method string
+		status int
+	}{
+		{"HEAD", http.StatusOK},
+		{"GET", http.StatusNoContent},
+		{"GET", http.StatusNotModified},
+		{"GET", http.StatusProcessing},
+	} {
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(testCase.method, "/v1/hello/world/1", nil)
+		assert.NoError(t, WriteResource(w, r, testCase.status, helloWorld))
+		assert.Equal(t, testCase.status, w.Code)
+		assert.Empty(t, w.Body.String(), testCase)
+		assert.Empty(t, w.Header().Get("Content-Length"), testCase)
+	}
+
+	// Test a slice of resources and embedded values which are not resources
+	other := &HelloWorld{ID: 2, Name: "Hello Again"}
+	other.SetLink("self", "/v1/hello/world/2", "")
+	other.SetEmbedded("tags", []string{"x"})
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/v1/hello/world", nil)
+	assert.NoError(t, WriteResource(w, r, http.StatusOK, []*HelloWorld{helloWorld, other}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(
+		t,
+		`[{"_links":{"self":{"href":"/v1/hello/world/1"}},"id":1,"name":"Hello World"},`+
+			`{"_links":{"self":{"href":"/v1/hello/world/2"}},"id":2,"name":"Hello Again","_embedded":{"tags":["x"]}}]`+"\n",
+		w.Body.String(),
+	)
+
+	// Test the same with hrefs resolved against a base URL
+	w = httptest.NewRecorder()
+	base, _ := url.Parse("https://api.example.com")
+	r = r.WithContext(context.WithValue(r.Context(), baseURLKey{}, base))
+	assert.NoError(t, WriteResource(w, r, http.StatusOK, []*HelloWorld{helloWorld, other}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(
+		t,
+		`[{"_links":{"self":{"href":"https://api.example.com/v1/hello/world/1"}},"id":1,"name":"Hello World"},`+
+			`{"_links":{"self":{"href":"https://api.example.com/v1/hello/world/2"}},"id":2,"name":"Hello Again","_embedded":{"tags":["x"]}}]`+"\n",
+		w.Body.String(),
+	)
+	link, _ := other.GetLink("self")
+	assert.Equal(t, "/v1/hello/world/2", link.Href)
+
+	// Test fields tagged as embedded are resolved as well
+	customer := &embeddingCustomer{Name: "Bob", Orders: []*embeddingOrder{{ID: 1}}}
+	customer.Orders[0].SetLink("self", "/v1/orders/1", "")
+	w = httptest.NewRecorder()
+	assert.NoError(t, WriteResource(w, r, http.StatusOK, customer))
+	assert.Contains(t, w.Body.String(), `"_embedded":{"orders":[{"_links":{"self":{"href":"https://api.example.com/v1/orders/1"}},"id":1}]}`)
+	link, _ = customer.Orders[0].GetLink("self")
+	assert.Equal(t, "/v1/orders/1", link.Href)
+
+	// Test compact relations with an unregistered CURIE are rejected
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/v1/hello/world/1", nil)
+	curied := &HelloWorld{ID: 1, Name: "Hello World"}
+	curied.SetLink("acme:widgets", "/v1/widgets", "")
+	err := WriteResource(w, r, http.StatusOK, curied)
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	w = httptest.NewRecorder()
+	assert.True(t, errors.Is(Negotiate(w, r, http.StatusOK, curied), ErrInvalidDocument))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	curied.AddCurie("acme", "http://docs.acme.com/rels/{rel}")
+	w = httptest.NewRecorder()
+	assert.NoError(t, WriteResource(w, r, http.StatusOK, curied))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Test an encoding error
+	w = httptest.NewRecorder()
+	r = httptest.NewRequest("GET", "/v1/hello/world/1", nil)
+	helloWorld.SetEmbedded("bogus", Embedded(func() {}))
+	assert.Error(t, WriteResource(w, r, http.StatusOK, helloWorld))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "application/hal+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"message":"Internal Server Error"}`+"\n", w.Body.String())
+}
+
+func TestHandlerFunc(t *testing.T) {
+	handler := HandlerFunc[*HelloWorld](func(r *http.Request) (int, *HelloWorld, error) {
+		switch r.URL.Path {
+		case "/v1/hello/world/1":
+			helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+			helloWorld.SetLink("self", r.URL.Path, "")
+			return http.StatusOK, helloWorld, nil
+		case "/v1/hello/world/2":
+			return 0, nil, NewHTTPError(http.StatusNotFound, errors.New("Hello world 2 not found"))
+		}
+		return 0, nil, errors.New("database is down")
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/hello/world/1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/hal+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"_links":{"self":{"href":"/v1/hello/world/1"}},"id":1,"name":"Hello World"}`+"\n", w.Body.String())
+
+	// Test client errors expose their message
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/hello/world/2", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "application/hal+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"message":"Hello world 2 not found"}`+"\n", w.Body.String())
+
+	// Test server errors do not expose their message
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/v1/hello/world/3", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, `{"message":"Internal Server Error"}`+"\n", w.Body.String())
+}
@@ -4,13 +4,99 @@
 package jsonhal
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 )
 
 // Link represents a link in "_links" object
 type Link struct {
-	Href  string `json:"href"`
-	Title string `json:"title,omitempty"`
+	Href        string `json:"href"`
+	Templated   bool   `json:"templated,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Deprecation string `json:"deprecation,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Hreflang    string `json:"hreflang,omitempty"`
+}
+
+// LinkOption sets an optional property of a link
+type LinkOption func(*Link)
+
+// Templated marks the link href as a URI template
+func Templated() LinkOption {
+	return func(l *Link) { l.Templated = true }
+}
+
+// MediaType sets a hint about the media type of the link target
+func MediaType(mediaType string) LinkOption {
+	return func(l *Link) { l.Type = mediaType }
+}
+
+// Deprecation sets a URL with information about the link deprecation
+func Deprecation(url string) LinkOption {
+	return func(l *Link) { l.Deprecation = url }
+}
+
+// Name sets a secondary key to select between links sharing the same relation
+func Name(name string) LinkOption {
+	return func(l *Link) { l.Name = name }
+}
+
+// Profile sets a URI hinting about the profile of the target resource
+func Profile(profile string) LinkOption {
+	return func(l *Link) { l.Profile = profile }
+}
+
+// Hreflang sets the language of the target resource
+func Hreflang(lang string) LinkOption {
+	return func(l *Link) { l.Hreflang = lang }
+}
+
+// LinkSet holds all links sharing the same relation in "_links" object.
+//
+// A set holding a single link marshals into a link object unless Array is
+// set, any other set marshals into an array of link objects
+type LinkSet struct {
+	Links []*Link
+	Array bool
+}
+
+// MarshalJSON encodes the link set as a single link object or as an array
+func (s LinkSet) MarshalJSON() ([]byte, error) {
+	if len(s.Links) == 1 && !s.Array {
+		return json.Marshal(s.Links[0])
+	}
+	if s.Links == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(s.Links)
+}
+
+// UnmarshalJSON decodes either a single link object or an array of links,
+// an array keeps its form when marshalled again
+func (s *LinkSet) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var links []*Link
+		if err := json.Unmarshal(data, &links); err != nil {
+			return err
+		}
+		for i, link := range links {
+			if link == nil {
+				return invalidDocument(fmt.Errorf("Link %d must be an object", i))
+			}
+		}
+		s.Links, s.Array = links, true
+		return nil
+	}
+	link := new(Link)
+	if err := json.Unmarshal(data, link); err != nil {
+		return err
+	}
+	s.Links, s.Array = []*Link{link}, false
+	return nil
 }
 
 // Embedded represents a resource in "_embedded" object
@@ -38,16 +124,64 @@ type Embedder interface {
 
 // Hal is used for composition, include it as anonymous field in your structs
 type Hal struct {
-	Links    map[string]*Link    `json:"_links,omitempty"`
-	Embedded map[string]Embedded `json:"_embedded,omitempty"`
+	Links     map[string]LinkSet  `json:"_links,omitempty"`
+	Embedded  map[string]Embedded `json:"_embedded,omitempty"`
+	Templates map[string]*Form    `json:"_templates,omitempty"`
+	// arrays are relations set to marshal into arrays by SetLinkArray,
+	// kept apart from Links so relations without links are left out
+	arrays map[string]bool
 }
 
-// SetLink sets a link (self, next, etc). Title argument is optional
-func (h *Hal) SetLink(name, href, title string) {
+// SetLink sets a link (self, next, etc). Title argument is optional,
+// other link properties can be set by passing options (Templated, Name etc).
+// Any links previously set under the same name are replaced
+func (h *Hal) SetLink(name, href, title string, options ...LinkOption) {
 	if h.Links == nil {
-		h.Links = make(map[string]*Link, 0)
+		h.Links = make(map[string]LinkSet, 0)
+	}
+	h.Links[name] = LinkSet{
+		Links: []*Link{newLink(href, title, options)},
+		Array: h.Links[name].Array || h.arrays[name],
 	}
-	h.Links[name] = &Link{Href: href, Title: title}
+}
+
+// AddLink appends a link to the links already set under the same name
+func (h *Hal) AddLink(name, href, title string, options ...LinkOption) {
+	if h.Links == nil {
+		h.Links = make(map[string]LinkSet, 0)
+	}
+	set, ok := h.Links[name]
+	if !ok {
+		set.Array = h.arrays[name]
+	}
+	set.Links = append(set.Links, newLink(href, title, options))
+	h.Links[name] = set
+}
+
+// SetLinkArray sets whether links named name always marshal into an array,
+// even when there is only one of them. It can be called before the links
+// are set, a relation without links is not marshalled
+func (h *Hal) SetLinkArray(name string, array bool) {
+	if array {
+		if h.arrays == nil {
+			h.arrays = make(map[string]bool)
+		}
+		h.arrays[name] = true
+	} else {
+		delete(h.arrays, name)
+	}
+	if set, ok := h.Links[name]; ok {
+		set.Array = array
+		h.Links[name] = set
+	}
+}
+
+func newLink(href, title string, options []LinkOption) *Link {
+	link := &Link{Href: href, Title: title}
+	for _, option := range options {
+		option(link)
+	}
+	return link
 }
 
 // DeleteLink removes a link named name if it is found
@@ -57,16 +191,27 @@ func (h *Hal) DeleteLink(name string) {
 	}
 }
 
-// GetLink returns a link by name or error
+// GetLink returns a link by name or error. When there are multiple links
+// under the same name the first one is returned
 func (h *Hal) GetLink(name string) (*Link, error) {
+	links, err := h.GetLinks(name)
+	if err != nil {
+		return nil, err
+	}
+	return links[0], nil
+}
+
+// GetLinks returns all links sharing the same name or error
+// matching ErrLinkNotFound
+func (h *Hal) GetLinks(name string) ([]*Link, error) {
 	if h.Links == nil {
-		return nil, fmt.Errorf("Link \"%s\" not found", name)
+		return nil, linkNotFound(name)
 	}
-	link, ok := h.Links[name]
-	if !ok {
-		return nil, fmt.Errorf("Link \"%s\" not found", name)
+	set, ok := h.Links[name]
+	if !ok || len(set.Links) == 0 {
+		return nil, linkNotFound(name)
 	}
-	return link, nil
+	return set.Links, nil
 }
 
 // SetEmbedded adds a slice of objects under a named key in the embedded map
@@ -78,13 +223,14 @@ func (h *Hal) SetEmbedded(name string, embedded Embedded) {
 }
 
 // GetEmbedded returns a slice of embedded resources by name or error
+// matching ErrEmbeddedNotFound
 func (h *Hal) GetEmbedded(name string) (Embedded, error) {
 	if h.Embedded == nil {
-		return nil, fmt.Errorf("Embedded \"%s\" not found", name)
+		return nil, embeddedNotFound(name)
 	}
 	embedded, ok := h.Embedded[name]
 	if !ok {
-		return nil, fmt.Errorf("Embedded \"%s\" not found", name)
+		return nil, embeddedNotFound(name)
 	}
 	return embedded, nil
 }
@@ -2,6 +2,7 @@ package jsonhal
 
 import (
 	"bytes"
+	"errors"
 	"log"
 	"reflect"
 	"testing"
@@ -246,8 +247,8 @@ func TestHal(t *testing.T) {
 	foobars = []*Foobar{
 		&Foobar{
 			Hal: Hal{
-				Links: map[string]*Link{
-					"self": &Link{Href: "/v1/foo/bar/1"},
+				Links: map[string]LinkSet{
+					"self": LinkSet{Links: []*Link{&Link{Href: "/v1/foo/bar/1"}}},
 				},
 			},
 			ID:   1,
@@ -255,8 +256,8 @@ func TestHal(t *testing.T) {
 		},
 		&Foobar{
 			Hal: Hal{
-				Links: map[string]*Link{
-					"self": &Link{Href: "/v1/foo/bar/2"},
+				Links: map[string]LinkSet{
+					"self": LinkSet{Links: []*Link{&Link{Href: "/v1/foo/bar/2"}}},
 				},
 			},
 			ID:   2,
@@ -289,8 +290,8 @@ func TestHal(t *testing.T) {
 	foobars = []*Foobar{
 		&Foobar{
 			Hal: Hal{
-				Links: map[string]*Link{
-					"self": &Link{Href: "/v1/foo/bar/1"},
+				Links: map[string]LinkSet{
+					"self": LinkSet{Links: []*Link{&Link{Href: "/v1/foo/bar/1"}}},
 				},
 			},
 			ID:   1,
@@ -298,8 +299,8 @@ func TestHal(t *testing.T) {
 		},
 		&Foobar{
 			Hal: Hal{
-				Links: map[string]*Link{
-					"self": &Link{Href: "/v1/foo/bar/2"},
+				Links: map[string]LinkSet{
+					"self": LinkSet{Links: []*Link{&Link{Href: "/v1/foo/bar/2"}}},
 				},
 			},
 			ID:   2,
@@ -312,8 +313,8 @@ func TestHal(t *testing.T) {
 	quxes = []*Qux{
 		&Qux{
 			Hal: Hal{
-				Links: map[string]*Link{
-					"self": &Link{Href: "/v1/qux/1"},
+				Links: map[string]LinkSet{
+					"self": LinkSet{Links: []*Link{&Link{Href: "/v1/qux/1"}}},
 				},
 			},
 			ID:   1,
@@ -321,8 +322,8 @@ func TestHal(t *testing.T) {
 		},
 		&Qux{
 			Hal: Hal{
-				Links: map[string]*Link{
-					"self": &Link{Href: "/v1/qux/2"},
+				Links: map[string]LinkSet{
+					"self": LinkSet{Links: []*Link{&Link{Href: "/v1/qux/2"}}},
 				},
 			},
 			ID:   2,
@@ -466,3 +467,173 @@ func TestDeleteEmbedded(t *testing.T) {
 	assert.EqualError(t, err, "Embedded \"bogus\" not found")
 
 }
+
+var expectedJSON6 = []byte(`{
+	"_links": {
+		"find": {
+			"href": "/v1/hello/world{?name}",
+			"templated": true,
+			"type": "application/hal+json",
+			"deprecation": "/docs/deprecations/find",
+			"name": "by-name",
+			"profile": "/profiles/hello-world",
+			"title": "Find hello worlds",
+			"hreflang": "en"
+		},
+		"self": {
+			"href": "/v1/hello/world/1"
+		}
+	},
+	"id": 1,
+	"name": "Hello World"
+}`)
+
+func TestSetLinkOptions(t *testing.T) {
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	helloWorld.SetLink(
+		"find",                   // name
+		"/v1/hello/world{?name}", // href
+		"Find hello worlds",      // title
+		Templated(),
+		MediaType("application/hal+json"),
+		Deprecation("/docs/deprecations/find"),
+		Name("by-name"),
+		Profile("/profiles/hello-world"),
+		Hreflang("en"),
+	)
+
+	// Assert JSON after marshalling is as expected
+	expected := bytes.NewBuffer([]byte{})
+	err := json.Compact(expected, expectedJSON6)
+	if err != nil {
+		log.Fatal(err)
+	}
+	actual, err := json.Marshal(helloWorld)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, expected.String(), string(actual))
+
+	// Assert all link properties survive a round trip
+	decoded := new(HelloWorld)
+	assert.NoError(t, json.Unmarshal(actual, decoded))
+	link, err := decoded.GetLink("find")
+	assert.NoError(t, err)
+	assert.Equal(t, &Link{
+		Href:        "/v1/hello/world{?name}",
+		Templated:   true,
+		Type:        "application/hal+json",
+		Deprecation: "/docs/deprecations/find",
+		Name:        "by-name",
+		Profile:     "/profiles/hello-world",
+		Title:       "Find hello worlds",
+		Hreflang:    "en",
+	}, link)
+}
+
+var expectedJSON7 = []byte(`{
+	"_links": {
+		"item": [
+			{
+				"href": "/v1/foo/bar/1"
+			},
+			{
+				"href": "/v1/foo/bar/2",
+				"title": "Foo bar 2"
+			}
+		],
+		"alternate": [
+			{
+				"href": "/v2/hello/world/1"
+			}
+		],
+		"self": {
+			"href": "/v1/hello/world/1"
+		}
+	},
+	"id": 1,
+	"name": "Hello World"
+}`)
+
+func TestAddLink(t *testing.T) {
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	helloWorld.AddLink("item", "/v1/foo/bar/1", "")
+	helloWorld.AddLink("item", "/v1/foo/bar/2", "Foo bar 2")
+	helloWorld.SetLinkArray("alternate", true)
+	helloWorld.AddLink("alternate", "/v2/hello/world/1", "")
+
+	links, err := helloWorld.GetLinks("item")
+	assert.NoError(t, err)
+	if assert.Len(t, links, 2) {
+		assert.Equal(t, "/v1/foo/bar/1", links[0].Href)
+		assert.Equal(t, "/v1/foo/bar/2", links[1].Href)
+	}
+
+	// GetLink returns the first link
+	link, err := helloWorld.GetLink("item")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/foo/bar/1", link.Href)
+
+	// Test getting bogus links
+	links, err = helloWorld.GetLinks("bogus")
+	assert.Nil(t, links)
+	assert.EqualError(t, err, "Link \"bogus\" not found")
+
+	// Assert JSON after marshalling is as expected
+	expected := bytes.NewBuffer([]byte{})
+	err = json.Compact(expected, expectedJSON7)
+	if err != nil {
+		log.Fatal(err)
+	}
+	actual, err := json.Marshal(helloWorld)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var expectedDoc, actualDoc interface{}
+	assert.NoError(t, json.Unmarshal(expected.Bytes(), &expectedDoc))
+	assert.NoError(t, json.Unmarshal(actual, &actualDoc))
+	assert.Equal(t, expectedDoc, actualDoc)
+
+	// Assert both single links and arrays decode back and keep their form
+	decoded := new(HelloWorld)
+	assert.NoError(t, json.Unmarshal(actual, decoded))
+	links, err = decoded.GetLinks("item")
+	assert.NoError(t, err)
+	assert.Len(t, links, 2)
+	assert.True(t, decoded.Links["alternate"].Array)
+	assert.False(t, decoded.Links["self"].Array)
+	reencoded, err := json.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, string(actual), string(reencoded))
+
+	// Null entries of link arrays are rejected
+	err = json.Unmarshal([]byte(`{"_links":{"item":[{"href":"/v1/foo/bar/1"},null]}}`), new(HelloWorld))
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+	assert.EqualError(t, err, "Link 1 must be an object")
+
+	// Setting a link replaces all links under the same name
+	helloWorld.SetLink("item", "/v1/foo/bar/3", "")
+	links, err = helloWorld.GetLinks("item")
+	assert.NoError(t, err)
+	assert.Len(t, links, 1)
+
+	// Relations set to marshal into arrays without links are left out
+	helloWorld = &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLinkArray("item", true)
+	helloWorld.SetLinkArray("next", true)
+	helloWorld.SetLinkArray("next", false)
+	actual, err = json.Marshal(helloWorld)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"Hello World"}`, string(actual))
+	helloWorld.SetLink("item", "/v1/foo/bar/1", "")
+	helloWorld.SetLink("next", "/v1/hello/world/2", "")
+	actual, err = json.Marshal(helloWorld)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`{"_links":{"item":[{"href":"/v1/foo/bar/1"}],"next":{"href":"/v1/hello/world/2"}},"id":1,"name":"Hello World"}`,
+		string(actual),
+	)
+}
@@ -0,0 +1,335 @@
+package jsonhal
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// LinkHeader returns links as a RFC 8288 Link header value, link relations
+// are written in the same order as by Encoder and every link of a relation
+// is written as a separate link value. Compact relations are expanded to
+// URIs with registered CURIEs, see ExpandRel. Title, type, hreflang, name,
+// profile and deprecation are written as target attributes, a title which
+// is not ASCII is written as title* encoded as defined by RFC 8187.
+// Templated links are left out as the header cannot express them
+// Web Linking specification: https://tools.ietf.org/html/rfc8288
+func (h *Hal) LinkHeader() string {
+	var values []string
+	for _, rel := range orderedKeys(h.Links, DefaultLinkOrder) {
+		// Relations with unregistered CURIEs are written as they are
+		expanded, err := h.ExpandRel(rel)
+		if err != nil {
+			expanded = rel
+		}
+		for _, link := range h.Links[rel].Links {
+			if link.Templated {
+				continue
+			}
+			values = append(values, formatLinkValue(expanded, link))
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+func formatLinkValue(rel string, link *Link) string {
+	buf := new(strings.Builder)
+	buf.WriteString("<" + link.Href + ">; rel=" + quoteLinkParam(rel))
+	if link.Title != "" && !isASCII(link.Title) {
+		buf.WriteString("; title*=" + encodeExtValue(link.Title))
+	}
+	for _, attr := range []struct{ name, value string }{
+		{"title", asciiOnly(link.Title)},
+		{"type", link.Type},
+		{"hreflang", link.Hreflang},
+		{"name", link.Name},
+		{"profile", link.Profile},
+		{"deprecation", link.Deprecation},
+	} {
+		if attr.value != "" {
+			buf.WriteString("; " + attr.name + "=" + quoteLinkParam(attr.value))
+		}
+	}
+	return buf.String()
+}
+
+func quoteLinkParam(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// ParseLinkHeader parses RFC 8288 Link header values into link sets keyed
+// by relation. A link value with several space separated relations is added
+// to each of them, links with the same relation keep their order. Title*
+// attributes encoded as defined by RFC 8187 take precedence over title.
+// Links with an anchor are left out as their context is another resource
+func ParseLinkHeader(values ...string) (map[string]LinkSet, error) {
+	links := make(map[string]LinkSet)
+	for _, value := range values {
+		p := &linkHeaderParser{s: value}
+		for {
+			p.skipSpace()
+			if p.done() {
+				break
+			}
+			if p.peek() == ',' {
+				p.pos++
+				continue
+			}
+			rels, link, err := p.parseLinkValue()
+			if err != nil {
+				return nil, fmt.Errorf("Invalid Link header \"%s\": %w", value, err)
+			}
+			for _, rel := range rels {
+				set := links[rel]
+				copied := *link
+				set.Links = append(set.Links, &copied)
+				links[rel] = set
+			}
+		}
+	}
+	return links, nil
+}
+
+type linkHeaderParser struct {
+	s   string
+	pos int
+}
+
+func (p *linkHeaderParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *linkHeaderParser) peek() byte {
+	return p.s[p.pos]
+}
+
+func (p *linkHeaderParser) skipSpace() {
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// parseLinkValue parses `<href>; param=value; ...` up to the next comma
+func (p *linkHeaderParser) parseLinkValue() ([]string, *Link, error) {
+	if p.peek() != '<' {
+		return nil, nil, fmt.Errorf("Expected '<' at position %d", p.pos)
+	}
+	end := strings.IndexByte(p.s[p.pos:], '>')
+	if end < 0 {
+		return nil, nil, fmt.Errorf("Unclosed '<' at position %d", p.pos)
+	}
+	link := &Link{Href: p.s[p.pos+1 : p.pos+end]}
+	p.pos += end + 1
+
+	var rels []string
+	var titleExt, anchor string
+	for {
+		p.skipSpace()
+		if p.done() || p.peek() == ',' {
+			break
+		}
+		if p.peek() != ';' {
+			return nil, nil, fmt.Errorf("Expected ';' at position %d", p.pos)
+		}
+		p.pos++
+		name, value, err := p.parseParam()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch name {
+		case "rel":
+			// Only the first occurrence of rel is used
+			if rels == nil {
+				rels = strings.Fields(value)
+			}
+		case "title":
+			link.Title = value
+		case "title*":
+			titleExt = value
+		case "type":
+			link.Type = value
+		case "hreflang":
+			link.Hreflang = value
+		case "name":
+			link.Name = value
+		case "profile":
+			link.Profile = value
+		case "deprecation":
+			link.Deprecation = value
+		case "anchor":
+			anchor = value
+		}
+	}
+	if titleExt != "" {
+		if title, ok := decodeExtValue(titleExt); ok {
+			link.Title = title
+		}
+	}
+	if len(rels) == 0 {
+		return nil, nil, fmt.Errorf("Link <%s> has no rel parameter", link.Href)
+	}
+	if anchor != "" {
+		return nil, link, nil
+	}
+	return rels, link, nil
+}
+
+// parseParam parses a `name=token` or `name="quoted string"` parameter
+func (p *linkHeaderParser) parseParam() (string, string, error) {
+	p.skipSpace()
+	start := p.pos
+	for !p.done() && strings.IndexByte(" \t=;,", p.peek()) < 0 {
+		p.pos++
+	}
+	name := strings.ToLower(p.s[start:p.pos])
+	if name == "" {
+		return "", "", fmt.Errorf("Expected parameter name at position %d", p.pos)
+	}
+	p.skipSpace()
+	if p.done() || p.peek() != '=' {
+		return name, "", nil
+	}
+	p.pos++
+	p.skipSpace()
+	if p.done() || p.peek() != '"' {
+		start = p.pos
+		for !p.done() && strings.IndexByte(" \t;,", p.peek()) < 0 {
+			p.pos++
+		}
+		return name, p.s[start:p.pos], nil
+	}
+
+	p.pos++
+	value := new(strings.Builder)
+	for !p.done() {
+		c := p.peek()
+		p.pos++
+		switch {
+		case c == '"':
+			return name, value.String(), nil
+		case c == '\\' && !p.done():
+			value.WriteByte(p.peek())
+			p.pos++
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", "", fmt.Errorf("Unclosed quoted string of parameter \"%s\"", name)
+}
+
+// encodeExtValue encodes value as a RFC 8187 ext-value in UTF-8 without
+// a language, bytes other than attr-char are percent encoded
+func encodeExtValue(value string) string {
+	buf := new(strings.Builder)
+	buf.WriteString("UTF-8''")
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if isAlpha(c) || isDigit(c) || strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			buf.WriteByte(c)
+		} else {
+			fmt.Fprintf(buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiOnly returns s if it is ASCII or an empty string, a title which
+// is not ASCII is written as title* only
+func asciiOnly(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	return ""
+}
+
+// decodeExtValue decodes a RFC 8187 ext-value, a charset and language
+// followed by a percent encoded value
+func decodeExtValue(value string) (string, bool) {
+	parts := strings.SplitN(value, "'", 3)
+	if len(parts) != 3 || !strings.EqualFold(parts[0], "UTF-8") {
+		return "", false
+	}
+	decoded, err := url.PathUnescape(parts[2])
+	if err != nil {
+		return "", false
+	}
+	return decoded, true
+}
+
+// SetLinksFromHeader sets links parsed from Link headers of header, links
+// previously set under the same relations are replaced
+func (h *Hal) SetLinksFromHeader(header http.Header) error {
+	links, err := ParseLinkHeader(header.Values("Link")...)
+	if err != nil {
+		return err
+	}
+	if len(links) > 0 && h.Links == nil {
+		h.Links = make(map[string]LinkSet, len(links))
+	}
+	for rel, set := range links {
+		h.Links[rel] = set
+	}
+	return nil
+}
+
+// WriteOption configures responses written by WriteResource and Negotiate
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	linkHeader bool
+}
+
+// WithLinkHeader mirrors links of the written resource into the Link
+// header, so clients reading headers only (or sending HEAD requests)
+// can see them as well. Hrefs are resolved the same way as in the body
+func WithLinkHeader() WriteOption {
+	return func(o *writeOptions) { o.linkHeader = true }
+}
+
+func newWriteOptions(options []WriteOption) *writeOptions {
+	o := new(writeOptions)
+	for _, option := range options {
+		option(o)
+	}
+	return o
+}
+
+// apply sets headers requested by options for resource v, hrefs of v
+// should already be resolved the same way as in the body
+func (o *writeOptions) apply(w http.ResponseWriter, v interface{}) {
+	if !o.linkHeader {
+		return
+	}
+	if h := halOf(v); h != nil {
+		if header := h.LinkHeader(); header != "" {
+			w.Header().Set("Link", header)
+		}
+	}
+}
+
+// halOf returns Hal of v, a struct embedding Hal or a pointer to one,
+// or nil if v does not embed Hal
+func halOf(v interface{}) *Hal {
+	if resource, ok := v.(halResource); ok {
+		return resource.hal()
+	}
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Struct || !reflect.PointerTo(value.Type()).Implements(halResourceType) {
+		return nil
+	}
+	ptr := reflect.New(value.Type())
+	ptr.Elem().Set(value)
+	return ptr.Interface().(halResource).hal()
+}
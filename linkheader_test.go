@@ -0,0 +1,128 @@
+package jsonhal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkHeader(t *testing.T) {
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	helloWorld.SetLink("search", "/v1/hello/world{?q}", "", Templated())
+	helloWorld.AddLink("alternate", "/v1/hello/world/1.xml", `Hello "World"`, MediaType("application/hal+xml"))
+	helloWorld.AddLink("alternate", "/de/v1/hello/world/1", "", Hreflang("de"), Name("de"), Profile("/profiles/hello"), Deprecation("/deprecated"))
+
+	header := helloWorld.LinkHeader()
+	assert.Equal(
+		t,
+		`</v1/hello/world/1>; rel="self", `+
+			`</v1/hello/world/1.xml>; rel="alternate"; title="Hello \"World\""; type="application/hal+xml", `+
+			`</de/v1/hello/world/1>; rel="alternate"; hreflang="de"; name="de"; profile="/profiles/hello"; deprecation="/deprecated"`,
+		header,
+	)
+
+	// Test parsing back, templated links are left out
+	links, err := ParseLinkHeader(header)
+	assert.NoError(t, err)
+	delete(helloWorld.Links, "search")
+	assert.Equal(t, helloWorld.Links, links)
+	assert.Equal(t, "", (&Hal{}).LinkHeader())
+
+	// Test compact relations are expanded and titles which are not ASCII
+	// are written as title*
+	h := new(Hal)
+	h.AddCurie("acme", "http://docs.acme.com/rels/{rel}")
+	h.SetLink("acme:widgets", "/v1/widgets", "€ rates")
+	h.SetLink("bogus:widgets", "/v1/bogus", "")
+	header = h.LinkHeader()
+	assert.Equal(
+		t,
+		`</v1/widgets>; rel="http://docs.acme.com/rels/widgets"; title*=UTF-8''%E2%82%AC%20rates, `+
+			`</v1/bogus>; rel="bogus:widgets"`,
+		header,
+	)
+	links, err = ParseLinkHeader(header)
+	assert.NoError(t, err)
+	assert.Equal(t, "€ rates", links["http://docs.acme.com/rels/widgets"].Links[0].Title)
+
+	// Test multiple values, relations and RFC 8187 titles
+	links, err = ParseLinkHeader(
+		`<https://example.com/2>; REL=next; rel=bogus, </1>;rel="prev first";title="a,b;c"`,
+		`</3>; rel=last; title="Last"; title*=UTF-8'en'%e2%82%ac%20rates, </4>; rel="last alternate"; anchor="/other"`,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]LinkSet{
+		"next":  {Links: []*Link{{Href: "https://example.com/2"}}},
+		"prev":  {Links: []*Link{{Href: "/1", Title: "a,b;c"}}},
+		"first": {Links: []*Link{{Href: "/1", Title: "a,b;c"}}},
+		"last":  {Links: []*Link{{Href: "/3", Title: "€ rates"}}},
+	}, links)
+
+	// Test errors
+	for _, value := range []string{
+		`/v1; rel=self`,
+		`</v1; rel=self`,
+		`</v1> rel=self`,
+		`</v1>; title=foo`,
+		`</v1>; rel="self`,
+		`</v1>; =self`,
+	} {
+		_, err := ParseLinkHeader(value)
+		assert.Error(t, err, value)
+	}
+	_, err = ParseLinkHeader(`</v1>; title=foo`)
+	assert.EqualError(t, err, "Invalid Link header \"</v1>; title=foo\": Link </v1> has no rel parameter")
+
+	// Test setting links from headers
+	h = new(Hal)
+	assert.NoError(t, h.SetLinksFromHeader(http.Header{"Link": {`</v1>; rel="self"`, `</v2>; rel="next"`}}))
+	assert.Equal(t, map[string]LinkSet{
+		"self": {Links: []*Link{{Href: "/v1"}}},
+		"next": {Links: []*Link{{Href: "/v2"}}},
+	}, h.Links)
+	assert.Error(t, h.SetLinksFromHeader(http.Header{"Link": {`bogus`}}))
+}
+
+func TestWithLinkHeader(t *testing.T) {
+	resolver, err := NewBaseURLResolver("10.0.0.1")
+	assert.NoError(t, err)
+	handler := resolver.Middleware(HandlerFunc[*HelloWorld](func(r *http.Request) (int, *HelloWorld, error) {
+		helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+		helloWorld.SetLink("self", "/v1/hello/world/1", "")
+		return http.StatusOK, helloWorld, nil
+	}).With(WithLinkHeader()))
+
+	for _, method := range []string{"GET", "HEAD"} {
+		r := httptest.NewRequest(method, "/v1/hello/world/1", nil)
+		r.RemoteAddr = "10.0.0.1:1234"
+		r.Header.Set("X-Forwarded-Host", "api.example.com")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `<http://api.example.com/v1/hello/world/1>; rel="self"`, w.Header().Get("Link"))
+	}
+
+	// Test negotiated representations
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	r := httptest.NewRequest("GET", "/v1/hello/world/1", nil)
+	r.Header.Set("Accept", JSONContentType)
+	w := httptest.NewRecorder()
+	assert.NoError(t, Negotiate(w, r, http.StatusOK, helloWorld, WithLinkHeader()))
+	assert.Equal(t, `</v1/hello/world/1>; rel="self"`, w.Header().Get("Link"))
+	assert.Equal(t, `{"id":1,"name":"Hello World"}`+"\n", w.Body.String())
+
+	// Resources written by value set the header as well
+	w = httptest.NewRecorder()
+	assert.NoError(t, WriteResource(w, r, http.StatusOK, *helloWorld, WithLinkHeader()))
+	assert.Equal(t, `</v1/hello/world/1>; rel="self"`, w.Header().Get("Link"))
+
+	// Resources without links do not set the header
+	w = httptest.NewRecorder()
+	assert.NoError(t, WriteResource(w, r, http.StatusOK, &HelloWorld{ID: 1}, WithLinkHeader()))
+	_, ok := w.Header()["Link"]
+	assert.False(t, ok)
+}
@@ -0,0 +1,145 @@
+package jsonhal
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// JSONContentType is the media type of plain JSON responses
+// without "_links" and "_embedded" objects
+const JSONContentType = "application/json"
+
+// Representations lists media types Negotiate can render
+// in order of preference
+var Representations = []string{ContentType, JSONContentType, XMLContentType}
+
+// Negotiate writes v, usually a struct embedding Hal, in the representation
+// the request Accept header prefers: HAL JSON, plain JSON without links and
+// embedded resources or HAL XML. When none of them is acceptable
+// a 406 Not Acceptable error is written and returned. CURIEs are validated
+// and options apply the same way as by WriteResource in all representations
+func Negotiate(w http.ResponseWriter, r *http.Request, status int, v interface{}, options ...WriteOption) error {
+	w.Header().Add("Vary", "Accept")
+	contentType := NegotiateContentType(r.Header.Get("Accept"), Representations...)
+	if contentType == "" {
+		err := NewHTTPError(http.StatusNotAcceptable, fmt.Errorf(
+			"None of %s is acceptable", strings.Join(Representations, ", "),
+		))
+		WriteError(w, r, err)
+		return err
+	}
+
+	resolved := resolveForRequest(r, v)
+	var data []byte
+	err := validateCuries(resolved)
+	if err == nil {
+		switch contentType {
+		case JSONContentType:
+			data, err = encodePlainJSON(r, resolved)
+		case XMLContentType:
+			indent := ""
+			if pretty(r) {
+				indent = "\t"
+			}
+			data, err = MarshalXMLIndent(resolved, "", indent)
+		default:
+			data, err = encodeResource(r, resolved)
+		}
+	}
+	if err != nil {
+		WriteError(w, r, err)
+		return err
+	}
+	newWriteOptions(options).apply(w, resolved)
+	writeResponse(w, r, status, contentType, data)
+	return nil
+}
+
+// encodePlainJSON encodes v leaving out "_links" and "_embedded" objects
+func encodePlainJSON(r *http.Request, v interface{}) ([]byte, error) {
+	resource, err := toResource(v)
+	if err != nil {
+		return nil, err
+	}
+	plain := *resource
+	plain.Hal = Hal{}
+	return encodeResource(r, plain)
+}
+
+// NegotiateContentType returns the offered media type the Accept header
+// prefers, taking quality values and specificity of media ranges into
+// account. Ties are resolved in order of offers. An empty Accept header
+// accepts the first offer, an empty string is returned if nothing matches
+func NegotiateContentType(accept string, offers ...string) string {
+	if strings.TrimSpace(accept) == "" {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := -1.0, -1
+		for _, mediaRange := range ranges {
+			if s := mediaRange.match(offer); s > specificity {
+				q, specificity = mediaRange.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// mediaRange is a single media range of an Accept header
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	ranges := make([]mediaRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		slash := strings.IndexByte(mediaType, '/')
+		if slash == -1 {
+			continue
+		}
+		mediaRange := mediaRange{typ: mediaType[:slash], subtype: mediaType[slash+1:], q: 1}
+		if q, ok := params["q"]; ok {
+			if mediaRange.q, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange)
+	}
+	return ranges
+}
+
+// match returns how specifically the range matches a media type:
+// 2 for an exact match, 1 for type/*, 0 for */* and -1 for no match
+func (m mediaRange) match(mediaType string) int {
+	slash := strings.IndexByte(mediaType, '/')
+	if slash == -1 {
+		return -1
+	}
+	typ, subtype := mediaType[:slash], mediaType[slash+1:]
+	switch {
+	case m.typ == typ && m.subtype == subtype:
+		return 2
+	case m.typ == typ && m.subtype == "*":
+		return 1
+	case m.typ == "*" && m.subtype == "*":
+		return 0
+	}
+	return -1
+}
@@ -0,0 +1,110 @@
+package jsonhal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var expectedXML = `<?xml version="1.0" encoding="UTF-8"?>
+<resource href="/v1/hello/world/1">
+	<link rel="next" href="/v1/hello/world/2" title="Next &amp; last"></link>
+	<link rel="search" href="/v1/hello/world{?name}" templated="true"></link>
+	<resource rel="foobars" href="/v1/foo/bar/1">
+		<id>1</id>
+		<name>Foo bar 1</name>
+	</resource>
+	<resource rel="foobars" href="/v1/foo/bar/2">
+		<id>2</id>
+		<name>Foo bar 2</name>
+	</resource>
+	<id>1</id>
+	<name>Hello World</name>
+</resource>
+`
+
+func TestNegotiate(t *testing.T) {
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	helloWorld.SetLink("next", "/v1/hello/world/2", "Next & last")
+	helloWorld.SetLink("search", "/v1/hello/world{?name}", "", Templated())
+	foobars := []*Foobar{
+		&Foobar{ID: 1, Name: "Foo bar 1"},
+		&Foobar{ID: 2, Name: "Foo bar 2"},
+	}
+	foobars[0].SetLink("self", "/v1/foo/bar/1", "")
+	foobars[1].SetLink("self", "/v1/foo/bar/2", "")
+	helloWorld.SetEmbedded("foobars", Embedded(foobars))
+
+	negotiate := func(accept, query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", "/v1/hello/world/1"+query, nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		Negotiate(w, r, http.StatusOK, helloWorld)
+		return w
+	}
+
+	// Test HAL JSON is the default
+	w := negotiate("", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/hal+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	assert.Contains(t, w.Body.String(), `"_links"`)
+
+	// Test plain JSON leaves out links and embedded resources
+	w = negotiate("application/json", "")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"id":1,"name":"Hello World"}`+"\n", w.Body.String())
+
+	// Test HAL XML
+	w = negotiate("application/hal+xml", "?pretty")
+	assert.Equal(t, "application/hal+xml", w.Header().Get("Content-Type"))
+	assert.Equal(t, expectedXML, w.Body.String())
+
+	// Test quality values and wildcards
+	w = negotiate("application/json;q=0.5, application/hal+xml;q=0.8, */*;q=0.1", "")
+	assert.Equal(t, "application/hal+xml", w.Header().Get("Content-Type"))
+
+	w = negotiate("application/*;q=0.5, application/json", "")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	w = negotiate("text/html, */*", "")
+	assert.Equal(t, "application/hal+json", w.Header().Get("Content-Type"))
+
+	w = negotiate("application/*, application/hal+json;q=0", "")
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	// Test nothing acceptable
+	w = negotiate("text/html, application/hal+json;q=0", "")
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Equal(t, "application/hal+json", w.Header().Get("Content-Type"))
+	assert.Equal(
+		t,
+		`{"message":"None of application/hal+json, application/json, application/hal+xml is acceptable"}`+"\n",
+		w.Body.String(),
+	)
+}
+
+func TestNegotiateXMLErrors(t *testing.T) {
+	resource := NewResource()
+	resource.SetProperty("not valid", 1)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "application/hal+xml")
+	err := Negotiate(w, r, http.StatusOK, resource)
+	assert.EqualError(t, err, "Property \"not valid\" is not a valid XML name")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	assert.Equal(t, "", NegotiateContentType(""))
+	assert.Equal(t, "text/plain", NegotiateContentType(" ", "text/plain", "text/html"))
+	assert.Equal(t, "text/html", NegotiateContentType("text/html;level=1", "text/plain", "text/html"))
+	assert.Equal(t, "text/html", NegotiateContentType("bogus, text/*;q=x, text/html", "text/plain", "text/html"))
+	assert.Equal(t, "", NegotiateContentType("text/plain", "bogus"))
+}
@@ -0,0 +1,173 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Resource is a generic HAL resource for documents which do not map onto
+// a Go struct. State properties keep their order and decoded values are
+// re-encoded exactly as they were received, embedded resources are decoded
+// into *Resource or []*Resource values
+type Resource struct {
+	Hal
+	keys       []string
+	properties map[string]interface{}
+}
+
+// NewResource returns a new empty resource
+func NewResource() *Resource {
+	return new(Resource)
+}
+
+// PropertyNames returns names of all state properties in order
+func (r *Resource) PropertyNames() []string {
+	names := make([]string, len(r.keys))
+	copy(names, r.keys)
+	return names
+}
+
+// GetProperty returns a state property by name. Decoded properties are
+// returned as generic values (numbers as json.Number), properties set with
+// SetProperty are returned as they were set
+func (r *Resource) GetProperty(name string) (interface{}, bool) {
+	value, ok := r.properties[name]
+	if !ok {
+		return nil, false
+	}
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		return value, true
+	}
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// DecodeProperty decodes a state property by name into v
+func (r *Resource) DecodeProperty(name string, v interface{}) error {
+	value, ok := r.properties[name]
+	if !ok {
+		return &NotFoundError{Kind: ErrPropertyNotFound, Name: name}
+	}
+	raw, ok := value.(json.RawMessage)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw, v)
+}
+
+// SetProperty sets a state property, a new property is added after
+// all existing ones while an existing property keeps its position
+func (r *Resource) SetProperty(name string, value interface{}) {
+	if r.properties == nil {
+		r.properties = make(map[string]interface{}, 0)
+	}
+	if _, ok := r.properties[name]; !ok {
+		r.keys = append(r.keys, name)
+	}
+	r.properties[name] = value
+}
+
+// DeleteProperty removes a state property named name if it is found
+func (r *Resource) DeleteProperty(name string) {
+	if _, ok := r.properties[name]; !ok {
+		return
+	}
+	delete(r.properties, name)
+	for i, key := range r.keys {
+		if key == name {
+			r.keys = append(r.keys[:i:i], r.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// MarshalJSON encodes the resource in the same order as Encoder with
+// the default section and link order: "_links", "_templates", state
+// properties in order and "_embedded" last
+func (r Resource) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := NewEncoder(nil).writeResource(buf, &r); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a HAL document into the resource, embedded
+// resources are decoded recursively into *Resource or []*Resource values
+func (r *Resource) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil {
+		return err
+	} else if token != json.Delim('{') {
+		return invalidDocument(fmt.Errorf("Resource must be an object"))
+	}
+
+	*r = Resource{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return err
+		}
+		switch key {
+		case "_links":
+			if err := json.Unmarshal(raw, &r.Links); err != nil {
+				return err
+			}
+		case "_embedded":
+			if err := r.decodeEmbedded(raw); err != nil {
+				return err
+			}
+		case "_templates":
+			if err := json.Unmarshal(raw, &r.Templates); err != nil {
+				return err
+			}
+		default:
+			r.SetProperty(key, raw)
+		}
+	}
+	_, err := decoder.Token()
+	return err
+}
+
+func (r *Resource) decodeEmbedded(data []byte) error {
+	var embedded map[string]json.RawMessage
+	if err := json.Unmarshal(data, &embedded); err != nil {
+		return err
+	}
+	if embedded == nil {
+		return nil
+	}
+	r.Embedded = make(map[string]Embedded, len(embedded))
+	for name, raw := range embedded {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) > 0 && raw[0] == '[' {
+			var resources []*Resource
+			if err := json.Unmarshal(raw, &resources); err != nil {
+				return fmt.Errorf("Embedded \"%s\": %w", name, err)
+			}
+			r.Embedded[name] = resources
+			continue
+		}
+		var resource *Resource
+		if err := json.Unmarshal(raw, &resource); err != nil {
+			return fmt.Errorf("Embedded \"%s\": %w", name, err)
+		}
+		r.Embedded[name] = resource
+	}
+	return nil
+}
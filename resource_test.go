@@ -0,0 +1,158 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var resourceJSON = []byte(`{
+	"_links": {
+		"self": {
+			"href": "/v1/orders/1"
+		},
+		"item": [
+			{
+				"href": "/v1/items/1"
+			}
+		]
+	},
+	"total": 30.00,
+	"currency": "USD",
+	"id": 12345678901234567890,
+	"shipping": {
+		"zip": "10001",
+		"city": "New York"
+	},
+	"tags": [],
+	"_embedded": {
+		"customer": {
+			"_links": {
+				"self": {
+					"href": "/v1/customers/1"
+				}
+			},
+			"name": "John"
+		},
+		"items": [
+			{
+				"quantity": 2,
+				"_embedded": {
+					"product": {
+						"sku": "abc"
+					}
+				}
+			},
+			{
+				"quantity": 1
+			}
+		]
+	}
+}`)
+
+func TestResource(t *testing.T) {
+	var (
+		resource *Resource
+		value    interface{}
+		ok       bool
+		err      error
+	)
+
+	resource = NewResource()
+	assert.NoError(t, json.Unmarshal(resourceJSON, resource))
+
+	// Test state properties keep their order and values
+	assert.Equal(t, []string{"total", "currency", "id", "shipping", "tags"}, resource.PropertyNames())
+	value, ok = resource.GetProperty("currency")
+	assert.True(t, ok)
+	assert.Equal(t, "USD", value)
+	value, ok = resource.GetProperty("id")
+	assert.True(t, ok)
+	assert.Equal(t, json.Number("12345678901234567890"), value)
+	value, ok = resource.GetProperty("bogus")
+	assert.False(t, ok)
+	assert.Nil(t, value)
+
+	var shipping struct {
+		City string `json:"city"`
+	}
+	assert.NoError(t, resource.DecodeProperty("shipping", &shipping))
+	assert.Equal(t, "New York", shipping.City)
+	err = resource.DecodeProperty("bogus", &shipping)
+	assert.True(t, errors.Is(err, ErrPropertyNotFound))
+	assert.EqualError(t, err, "Property \"bogus\" not found")
+
+	// Test links and embedded resources
+	link, err := resource.GetLink("self")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/orders/1", link.Href)
+
+	customer, err := GetEmbeddedAs[*Resource](resource, "customer")
+	assert.NoError(t, err)
+	value, _ = customer.GetProperty("name")
+	assert.Equal(t, "John", value)
+
+	items, err := GetEmbeddedSlice[*Resource](resource, "items")
+	assert.NoError(t, err)
+	if assert.Len(t, items, 2) {
+		product, err := GetEmbeddedAs[*Resource](items[0], "product")
+		assert.NoError(t, err)
+		value, _ = product.GetProperty("sku")
+		assert.Equal(t, "abc", value)
+	}
+
+	// Test re-encoding is faithful
+	expected := bytes.NewBuffer([]byte{})
+	err = json.Compact(expected, resourceJSON)
+	if err != nil {
+		log.Fatal(err)
+	}
+	actual, err := json.Marshal(resource)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), string(actual))
+
+	// Test modifying properties
+	resource.SetProperty("currency", "EUR")
+	resource.SetProperty("paid", true)
+	resource.DeleteProperty("shipping")
+	resource.DeleteProperty("tags")
+	resource.DeleteProperty("bogus")
+	resource.DeleteLink("item")
+	resource.DeleteEmbedded("items")
+	resource.DeleteEmbedded("customer")
+	assert.Equal(t, []string{"total", "currency", "id", "paid"}, resource.PropertyNames())
+	value, _ = resource.GetProperty("paid")
+	assert.Equal(t, true, value)
+
+	actual, err = json.Marshal(resource)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`{"_links":{"self":{"href":"/v1/orders/1"}},"total":30.00,"currency":"EUR","id":12345678901234567890,"paid":true}`,
+		string(actual),
+	)
+
+	// Test resources built from scratch and encoded by value
+	resource = new(Resource)
+	resource.SetLink("self", "/v1/hello/world/1", "")
+	resource.SetProperty("id", 1)
+	resource.SetProperty("name", "Hello World")
+	expected = bytes.NewBuffer([]byte{})
+	err = json.Compact(expected, expectedJSON2)
+	if err != nil {
+		log.Fatal(err)
+	}
+	actual, err = json.Marshal(*resource)
+	assert.NoError(t, err)
+	assert.Equal(t, expected.String(), string(actual))
+
+	// Test decoding errors
+	err = json.Unmarshal([]byte(`[]`), new(Resource))
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+	err = json.Unmarshal([]byte(`{"_embedded": {"items": [1]}}`), new(Resource))
+	assert.Error(t, err)
+}
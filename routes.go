@@ -0,0 +1,229 @@
+package jsonhal
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Route is a named net/http ServeMux pattern such as "GET /v1/orders/{id}"
+type Route struct {
+	Name    string
+	Pattern string
+	Method  string
+	Host    string
+	Path    string
+	// segments of the path, wildcards are stored with braces
+	segments []string
+}
+
+// Router is a ServeMux which names its patterns so links to them can be
+// built with SetRouteLink rather than duplicating patterns as literals.
+// Patterns with methods and wildcards require ServeMux of Go 1.22, the main
+// module has to declare go 1.22 or later in its go.mod file and GODEBUG must
+// not set httpmuxgo121=1
+type Router struct {
+	mux    *http.ServeMux
+	mu     sync.RWMutex
+	routes map[string]*Route
+}
+
+// NewRouter returns a new empty router
+func NewRouter() *Router {
+	return &Router{mux: http.NewServeMux(), routes: make(map[string]*Route)}
+}
+
+// Handle registers handler for pattern under name, it panics if the name
+// is already registered or the pattern is invalid, see http.ServeMux. It
+// panics as well when ServeMux does not support Go 1.22 patterns as they
+// would be registered as literal paths which never match
+func (rt *Router) Handle(name, pattern string, handler http.Handler) {
+	if !servesPatterns() {
+		panic("Router requires ServeMux patterns of Go 1.22, GODEBUG sets httpmuxgo121=1")
+	}
+	route, err := parseRoute(name, pattern)
+	if err != nil {
+		panic(err)
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if _, ok := rt.routes[name]; ok {
+		panic(fmt.Sprintf("Route \"%s\" is already registered", name))
+	}
+	rt.mux.Handle(pattern, handler)
+	rt.routes[name] = route
+}
+
+// HandleFunc registers handler function for pattern under name, see Handle
+func (rt *Router) HandleFunc(name, pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	rt.Handle(name, pattern, http.HandlerFunc(handler))
+}
+
+// ServeHTTP dispatches the request to the handler of the matching pattern
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.mux.ServeHTTP(w, r)
+}
+
+// Route returns a route by name or error matching ErrRouteNotFound
+func (rt *Router) Route(name string) (*Route, error) {
+	rt.mu.RLock()
+	defer rt.mu.RUnlock()
+	route, ok := rt.routes[name]
+	if !ok {
+		return nil, &NotFoundError{Kind: ErrRouteNotFound, Name: name}
+	}
+	return route, nil
+}
+
+// servesPatterns reports whether ServeMux supports method and wildcard
+// patterns, the legacy ServeMux is used when GODEBUG has httpmuxgo121=1
+// which is the default for main modules declaring go older than 1.22
+var servesPatterns = sync.OnceValue(func() bool {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{id}", func(w http.ResponseWriter, r *http.Request) {})
+	_, pattern := mux.Handler(&http.Request{Method: "GET", URL: &url.URL{Path: "/1"}})
+	return pattern != ""
+})
+
+// URL returns the path of a named route with wildcards replaced by params,
+// see Route.URL
+func (rt *Router) URL(name string, params map[string]interface{}) (string, error) {
+	route, err := rt.Route(name)
+	if err != nil {
+		return "", err
+	}
+	return route.URL(params)
+}
+
+// URL returns the route path with wildcards replaced by params. Values are
+// escaped, except slashes of a {name...} wildcard value. A missing parameter
+// results in an error matching ErrMissingParameter
+func (r *Route) URL(params map[string]interface{}) (string, error) {
+	return r.expand(params, false)
+}
+
+// Template returns the route path as a URI template, wildcards found in
+// params are replaced by their values and the rest are left open
+func (r *Route) Template(params map[string]interface{}) (string, error) {
+	return r.expand(params, true)
+}
+
+func (r *Route) expand(params map[string]interface{}, templated bool) (string, error) {
+	for name := range params {
+		if !r.hasWildcard(name) {
+			return "", fmt.Errorf("Route \"%s\" has no parameter \"%s\"", r.Name, name)
+		}
+	}
+	buf := new(strings.Builder)
+	for _, segment := range r.segments {
+		buf.WriteByte('/')
+		name, remainder, wildcard := parseWildcard(segment)
+		if !wildcard {
+			buf.WriteString(segment)
+			continue
+		}
+		if name == "$" {
+			continue
+		}
+		value, ok := params[name]
+		if !ok || value == nil {
+			if !templated {
+				return "", fmt.Errorf("Route \"%s\": %w \"%s\"", r.Name, ErrMissingParameter, name)
+			}
+			if remainder {
+				buf.WriteString("{+" + name + "}")
+			} else {
+				buf.WriteString("{" + name + "}")
+			}
+			continue
+		}
+		s, err := scalarString(reflect.ValueOf(value))
+		if err != nil {
+			return "", fmt.Errorf("Route \"%s\" parameter \"%s\": %w", r.Name, name, err)
+		}
+		if !remainder {
+			buf.WriteString(url.PathEscape(s))
+			continue
+		}
+		parts := strings.Split(s, "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+		buf.WriteString(strings.Join(parts, "/"))
+	}
+	return buf.String(), nil
+}
+
+func (r *Route) hasWildcard(name string) bool {
+	for _, segment := range r.segments {
+		if wildcard, _, ok := parseWildcard(segment); ok && wildcard == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRoute parses a ServeMux pattern "[METHOD ][HOST]/[PATH]"
+func parseRoute(name, pattern string) (*Route, error) {
+	route := &Route{Name: name, Pattern: pattern}
+	rest := strings.TrimSpace(pattern)
+	if method, path, ok := strings.Cut(rest, " "); ok {
+		route.Method, rest = method, strings.TrimSpace(path)
+	}
+	slash := strings.IndexByte(rest, '/')
+	if slash < 0 {
+		return nil, fmt.Errorf("Route \"%s\": pattern \"%s\" has no path", name, pattern)
+	}
+	route.Host, route.Path = rest[:slash], rest[slash:]
+	segments := strings.Split(route.Path[1:], "/")
+	for i, segment := range segments {
+		wildcard, remainder, ok := parseWildcard(segment)
+		if !ok {
+			if strings.ContainsAny(segment, "{}") {
+				return nil, fmt.Errorf("Route \"%s\": invalid segment \"%s\"", name, segment)
+			}
+			continue
+		}
+		if (remainder || wildcard == "$") && i != len(segments)-1 {
+			return nil, fmt.Errorf("Route \"%s\": wildcard \"%s\" must be last", name, segment)
+		}
+	}
+	route.segments = segments
+	return route, nil
+}
+
+// parseWildcard parses a path segment such as {id}, {path...} or {$}
+func parseWildcard(segment string) (string, bool, bool) {
+	if len(segment) < 3 || segment[0] != '{' || segment[len(segment)-1] != '}' {
+		return "", false, false
+	}
+	name := segment[1 : len(segment)-1]
+	remainder := strings.HasSuffix(name, "...")
+	return strings.TrimSuffix(name, "..."), remainder, true
+}
+
+// SetRouteLink sets a link to a route of router named name, see SetLink.
+// Wildcards of the route pattern are replaced by params, a missing parameter
+// results in an error matching ErrMissingParameter unless the link is
+// templated with the Templated option, then missing parameters are left
+// open as URI template variables
+func (h *Hal) SetRouteLink(rel string, router *Router, name string, params map[string]interface{}, title string, options ...LinkOption) error {
+	route, err := router.Route(name)
+	if err != nil {
+		return err
+	}
+	var href string
+	if newLink("", "", options).Templated {
+		href, err = route.Template(params)
+	} else {
+		href, err = route.URL(params)
+	}
+	if err != nil {
+		return err
+	}
+	h.SetLink(rel, href, title, options...)
+	return nil
+}
@@ -0,0 +1,93 @@
+package jsonhal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	router := NewRouter()
+	if !servesPatterns() {
+		assert.Panics(t, func() { router.Handle("root", "/", http.NotFoundHandler()) })
+		t.Skip("ServeMux does not support patterns, GODEBUG sets httpmuxgo121=1")
+	}
+
+	router.HandleFunc("order", "GET /v1/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		order := &HelloWorld{Name: r.PathValue("id")}
+		assert.NoError(t, order.SetRouteLink("self", router, "order", map[string]interface{}{"id": r.PathValue("id")}, "Order"))
+		assert.NoError(t, order.SetRouteLink("files", router, "files", nil, "", Templated()))
+		WriteResource(w, r, http.StatusOK, order)
+	})
+	router.HandleFunc("files", "example.com/v1/files/{path...}", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("root", "/{$}", func(w http.ResponseWriter, r *http.Request) {})
+	router.HandleFunc("orders", "POST /v1/orders/", func(w http.ResponseWriter, r *http.Request) {})
+
+	// Test routing and building links in handlers
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/v1/orders/a%20b", nil))
+	assert.Equal(
+		t,
+		`{"_links":{"self":{"href":"/v1/orders/a%20b","title":"Order"},"files":{"href":"/v1/files/{+path}","templated":true}},"id":0,"name":"a b"}`+"\n",
+		w.Body.String(),
+	)
+
+	route, err := router.Route("order")
+	assert.NoError(t, err)
+	assert.Equal(t, &Route{
+		Name:     "order",
+		Pattern:  "GET /v1/orders/{id}",
+		Method:   "GET",
+		Path:     "/v1/orders/{id}",
+		segments: []string{"v1", "orders", "{id}"},
+	}, route)
+	route, err = router.Route("files")
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", route.Host)
+
+	testCases := []struct {
+		name     string
+		params   map[string]interface{}
+		expected string
+	}{
+		{"order", map[string]interface{}{"id": 1}, "/v1/orders/1"},
+		{"order", map[string]interface{}{"id": "a/b"}, "/v1/orders/a%2Fb"},
+		{"files", map[string]interface{}{"path": "a b/c.txt"}, "/v1/files/a%20b/c.txt"},
+		{"root", nil, "/"},
+		{"orders", nil, "/v1/orders/"},
+	}
+	for _, testCase := range testCases {
+		href, err := router.URL(testCase.name, testCase.params)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, href)
+	}
+
+	// Test errors
+	_, err = router.URL("bogus", nil)
+	assert.True(t, errors.Is(err, ErrRouteNotFound))
+	assert.EqualError(t, err, "Route \"bogus\" not found")
+	_, err = router.URL("order", nil)
+	assert.True(t, errors.Is(err, ErrMissingParameter))
+	assert.EqualError(t, err, "Route \"order\": missing parameter \"id\"")
+	_, err = router.URL("order", map[string]interface{}{"id": 1, "name": "foo"})
+	assert.EqualError(t, err, "Route \"order\" has no parameter \"name\"")
+
+	h := new(Hal)
+	err = h.SetRouteLink("self", router, "order", nil, "")
+	assert.True(t, errors.Is(err, ErrMissingParameter))
+	_, err = h.GetLink("self")
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+	assert.True(t, errors.Is(h.SetRouteLink("self", router, "bogus", nil, ""), ErrRouteNotFound))
+
+	assert.Panics(t, func() {
+		router.HandleFunc("order", "GET /v2/orders/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	assert.Panics(t, func() {
+		router.HandleFunc("bogus", "GET /v1/{path...}/bogus", func(w http.ResponseWriter, r *http.Request) {})
+	})
+	_, err = router.Route("bogus")
+	assert.Error(t, err)
+}
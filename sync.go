@@ -0,0 +1,150 @@
+package jsonhal
+
+import (
+	"sync"
+)
+
+// SyncHal is a concurrency-safe variant of Hal for building links, embedded
+// resources and templates from several goroutines. It cannot be marshaled
+// itself, take a Snapshot and assign it to the Hal of the resource instead:
+//
+//	var h jsonhal.SyncHal
+//	// ... fan out, each goroutine calls h.SetEmbedded
+//	order.Hal = h.Snapshot()
+//
+// The zero value is ready to use, a SyncHal must not be copied after first use
+type SyncHal struct {
+	mu  sync.RWMutex
+	hal Hal
+}
+
+// SetLink sets a link, see Hal.SetLink
+func (s *SyncHal) SetLink(name, href, title string, options ...LinkOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hal.SetLink(name, href, title, options...)
+}
+
+// AddLink appends a link, see Hal.AddLink
+func (s *SyncHal) AddLink(name, href, title string, options ...LinkOption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hal.AddLink(name, href, title, options...)
+}
+
+// SetLinkArray sets whether links named name always marshal into an array,
+// see Hal.SetLinkArray
+func (s *SyncHal) SetLinkArray(name string, array bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hal.SetLinkArray(name, array)
+}
+
+// DeleteLink removes a link named name if it is found
+func (s *SyncHal) DeleteLink(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hal.DeleteLink(name)
+}
+
+// GetLink returns a copy of a link by name or error, see Hal.GetLink
+func (s *SyncHal) GetLink(name string) (*Link, error) {
+	links, err := s.GetLinks(name)
+	if err != nil {
+		return nil, err
+	}
+	return links[0], nil
+}
+
+// GetLinks returns copies of all links sharing the same name or error
+// matching ErrLinkNotFound
+func (s *SyncHal) GetLinks(name string) ([]*Link, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	links, err := s.hal.GetLinks(name)
+	if err != nil {
+		return nil, err
+	}
+	return copyLinkSet(LinkSet{Links: links}).Links, nil
+}
+
+// SetEmbedded sets an embedded resource, see Hal.SetEmbedded
+func (s *SyncHal) SetEmbedded(name string, embedded Embedded) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hal.SetEmbedded(name, embedded)
+}
+
+// GetEmbedded returns an embedded resource by name or error
+// matching ErrEmbeddedNotFound
+func (s *SyncHal) GetEmbedded(name string) (Embedded, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hal.GetEmbedded(name)
+}
+
+// DeleteEmbedded removes an embedded resource named name if it is found
+func (s *SyncHal) DeleteEmbedded(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hal.DeleteEmbedded(name)
+}
+
+// SetTemplate sets a HAL-FORMS template, see Hal.SetTemplate
+func (s *SyncHal) SetTemplate(name string, form *Form) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hal.SetTemplate(name, form)
+}
+
+// GetTemplate returns a form by name or error matching ErrTemplateNotFound
+func (s *SyncHal) GetTemplate(name string) (*Form, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.hal.GetTemplate(name)
+}
+
+// DeleteTemplate removes a form named name if it is found
+func (s *SyncHal) DeleteTemplate(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hal.DeleteTemplate(name)
+}
+
+// Snapshot returns a copy of the current state which can be marshaled
+// while writers are still active. Maps and links are copied, embedded
+// resources and forms are shared with the SyncHal
+func (s *SyncHal) Snapshot() Hal {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var snapshot Hal
+	if s.hal.Links != nil {
+		snapshot.Links = make(map[string]LinkSet, len(s.hal.Links))
+		for name, set := range s.hal.Links {
+			snapshot.Links[name] = copyLinkSet(set)
+		}
+	}
+	if s.hal.Embedded != nil {
+		snapshot.Embedded = make(map[string]Embedded, len(s.hal.Embedded))
+		for name, embedded := range s.hal.Embedded {
+			snapshot.Embedded[name] = embedded
+		}
+	}
+	if s.hal.Templates != nil {
+		snapshot.Templates = make(map[string]*Form, len(s.hal.Templates))
+		for name, form := range s.hal.Templates {
+			snapshot.Templates[name] = form
+		}
+	}
+	snapshot.arrays = copyMap(s.hal.arrays, 0)
+	return snapshot
+}
+
+func copyLinkSet(set LinkSet) LinkSet {
+	links := make([]*Link, len(set.Links))
+	for i, link := range set.Links {
+		copied := *link
+		links[i] = &copied
+	}
+	return LinkSet{Links: links, Array: set.Array}
+}
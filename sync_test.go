@@ -0,0 +1,79 @@
+package jsonhal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncHal(t *testing.T) {
+	var (
+		h  SyncHal
+		wg sync.WaitGroup
+	)
+	var _ Embedder = &h
+
+	// Write, read and snapshot from several goroutines at once,
+	// run with -race to detect unsynchronised access
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("foobar%d", i)
+			h.SetEmbedded(name, &Foobar{ID: uint(i)})
+			h.AddLink("item", fmt.Sprintf("/v1/foo/bar/%d", i), "")
+			h.SetLink(name, fmt.Sprintf("/v1/foo/bar/%d", i), "")
+			h.SetTemplate(name, NewForm("PUT", ""))
+
+			_, err := h.GetEmbedded(name)
+			assert.NoError(t, err)
+			_, err = h.GetLinks("item")
+			assert.NoError(t, err)
+
+			snapshot := h.Snapshot()
+			_, err = json.Marshal(&HelloWorld{Hal: snapshot})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	snapshot := h.Snapshot()
+	assert.Len(t, snapshot.Embedded, 50)
+	assert.Len(t, snapshot.Templates, 50)
+	assert.Len(t, snapshot.Links, 51)
+	assert.Len(t, snapshot.Links["item"].Links, 50)
+
+	// Snapshots and returned links are not affected by later writes
+	link, err := h.GetLink("foobar1")
+	assert.NoError(t, err)
+	link.Href = "/v1/bogus"
+	h.SetLink("foobar1", "/v1/foo/bar/100", "")
+	h.DeleteEmbedded("foobar1")
+	h.DeleteTemplate("foobar1")
+	assert.Equal(t, "/v1/foo/bar/1", snapshot.Links["foobar1"].Links[0].Href)
+	assert.Contains(t, snapshot.Embedded, "foobar1")
+	assert.Contains(t, snapshot.Templates, "foobar1")
+
+	link, err = h.GetLink("foobar1")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/foo/bar/100", link.Href)
+
+	h.DeleteLink("foobar1")
+	_, err = h.GetLink("foobar1")
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+	_, err = h.GetEmbedded("foobar1")
+	assert.True(t, errors.Is(err, ErrEmbeddedNotFound))
+	_, err = h.GetTemplate("foobar1")
+	assert.True(t, errors.Is(err, ErrTemplateNotFound))
+
+	h.SetLinkArray("foobar2", true)
+	assert.True(t, h.Snapshot().Links["foobar2"].Array)
+	h.SetLinkArray("foobar100", true)
+	assert.NotContains(t, h.Snapshot().Links, "foobar100")
+	h.AddLink("foobar100", "/v1/foo/bar/100", "")
+	assert.True(t, h.Snapshot().Links["foobar100"].Array)
+}
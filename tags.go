@@ -0,0 +1,339 @@
+package jsonhal
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TagError is returned when a hal struct tag is malformed, tags of a type
+// are validated once on first use and the same error is returned afterwards
+type TagError struct {
+	Type  reflect.Type
+	Field string
+	Err   error
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("Invalid hal tag of %s.%s: %s", e.Type, e.Field, e.Err)
+}
+
+// Is reports whether target is ErrInvalidTag
+func (e *TagError) Is(target error) bool {
+	return target == ErrInvalidTag
+}
+
+// Unwrap returns the underlying error
+func (e *TagError) Unwrap() error {
+	return e.Err
+}
+
+// tagLink is a link declared in a hal struct tag
+type tagLink struct {
+	rel       string
+	title     string
+	href      *URITemplate
+	templated bool
+	omitempty bool
+	options   []LinkOption
+	fields    map[string][]int // index paths of fields the variables refer to
+}
+
+// tagEmbedded is a field moved into "_embedded" object by a hal struct tag
+type tagEmbedded struct {
+	rel   string
+	name  string // JSON name of the field or an empty string if it is hidden
+	index []int
+}
+
+// typeTags are links and embedded fields declared by a type
+// or an error if its tags are invalid
+type typeTags struct {
+	links    []*tagLink
+	embedded []*tagEmbedded
+	err      error
+}
+
+// taggedField is a field with a hal tag and its index path
+type taggedField struct {
+	reflect.StructField
+	index []int
+}
+
+var typeTagsCache sync.Map // map[reflect.Type]*typeTags
+
+// SetTaggedLinks sets links declared in hal struct tags of v, which should be
+// a pointer to a struct embedding Hal. A tag can be set on any field and
+// declares one or more links separated by semicolons:
+//
+//	type Order struct {
+//		jsonhal.Hal `hal:"link=self,href=/v1/orders/{id}"`
+//		ID          int `json:"id"`
+//		CustomerID  int `json:"customerId" hal:"link=customer,href=/v1/customers/{customerId},omitempty"`
+//	}
+//
+// Href is a URI template whose variables are expanded from values of fields
+// with the same JSON names. Other supported attributes are title, type, name,
+// profile, hreflang and deprecation. A link marked templated is set with its
+// href unexpanded, a link marked omitempty is skipped when any of the fields
+// it refers to has a zero value. Other links referring to a nil pointer or
+// a field promoted through one result in an error matching
+// ErrMissingParameter. Links previously set for relations declared in tags
+// are replaced, several links declared for the same relation are set as
+// a link array in order of declaration.
+//
+// Tags of a type are validated on first use, malformed tags, invalid
+// templates and variables not matching any field result in an error
+// matching ErrInvalidTag
+func SetTaggedLinks(v interface{}) error {
+	resource, ok := v.(halResource)
+	value := reflect.ValueOf(v)
+	if !ok || value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Value of type %T is not a pointer to a struct embedding Hal", v)
+	}
+	value = value.Elem()
+
+	declared := structTags(value.Type())
+	if declared.err != nil {
+		return declared.err
+	}
+
+	hrefs := make([]string, len(declared.links))
+	skipped := make([]bool, len(declared.links))
+	for i, link := range declared.links {
+		var err error
+		if hrefs[i], skipped[i], err = link.expand(value); err != nil {
+			return err
+		}
+	}
+
+	h := resource.hal()
+	for _, link := range declared.links {
+		h.DeleteLink(link.rel)
+	}
+	for i, link := range declared.links {
+		if !skipped[i] {
+			h.AddLink(link.rel, hrefs[i], link.title, link.options...)
+		}
+	}
+	return nil
+}
+
+// expand returns the link href expanded from field values of v
+// or reports the link should be skipped
+func (l *tagLink) expand(v reflect.Value) (string, bool, error) {
+	values := make(map[string]interface{}, len(l.fields))
+	for name, index := range l.fields {
+		// A field is undefined when it is promoted through a nil pointer
+		field, err := v.FieldByIndexErr(index)
+		undefined := err != nil || ((field.Kind() == reflect.Ptr || field.Kind() == reflect.Interface) && field.IsNil())
+		switch {
+		case l.omitempty && (undefined || field.IsZero()):
+			return "", true, nil
+		case undefined && !l.templated:
+			return "", false, fmt.Errorf("Link \"%s\": %w \"%s\"", l.rel, ErrMissingParameter, name)
+		case !undefined:
+			values[name] = field.Interface()
+		}
+	}
+	if l.templated {
+		return l.href.String(), false, nil
+	}
+	href, err := l.href.Expand(values)
+	if err != nil {
+		return "", false, fmt.Errorf("Link \"%s\": %w", l.rel, err)
+	}
+	return href, false, nil
+}
+
+// structTags returns links and embedded fields declared by a struct
+// type, parsing and validating its tags on first use
+func structTags(t reflect.Type) *typeTags {
+	if cached, ok := typeTagsCache.Load(t); ok {
+		return cached.(*typeTags)
+	}
+	declared := new(typeTags)
+	fields := make(map[string][]int)
+	var tagged []taggedField
+	collectFields(t, nil, fields, &tagged)
+	for _, field := range tagged {
+		tag := field.Tag.Get("hal")
+		if strings.HasPrefix(tag, "embedded=") {
+			embedded, err := parseEmbeddedTag(tag, field)
+			if err != nil {
+				declared = &typeTags{err: &TagError{Type: t, Field: field.Name, Err: err}}
+				break
+			}
+			declared.embedded = append(declared.embedded, embedded)
+			continue
+		}
+		links, err := parseLinkTag(tag, fields)
+		if err != nil {
+			declared = &typeTags{err: &TagError{Type: t, Field: field.Name, Err: err}}
+			break
+		}
+		declared.links = append(declared.links, links...)
+	}
+	cached, _ := typeTagsCache.LoadOrStore(t, declared)
+	return cached.(*typeTags)
+}
+
+var halType = reflect.TypeOf(Hal{})
+
+// collectFields maps JSON names of fields of t, including fields promoted
+// from embedded structs, to their index paths and collects fields with
+// hal tags. Fields of Hal itself are not collected
+func collectFields(t reflect.Type, index []int, fields map[string][]int, tagged *[]taggedField) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		path := append(index[:len(index):len(index)], i)
+		if tag, ok := field.Tag.Lookup("hal"); ok && tag != "" {
+			*tagged = append(*tagged, taggedField{StructField: field, index: path})
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded != halType && embedded.Kind() == reflect.Struct {
+				collectFields(embedded, path, fields, tagged)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := fields[name]; !ok {
+			fields[name] = path
+		}
+	}
+}
+
+// splitTag splits s around sep outside of URI template expressions,
+// which can contain commas themselves
+func splitTag(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseEmbeddedTag parses a hal struct tag moving field into "_embedded"
+// object, only structs, pointers and slices can be embedded
+func parseEmbeddedTag(tag string, field taggedField) (*tagEmbedded, error) {
+	rel := strings.TrimPrefix(tag, "embedded=")
+	if rel == "" {
+		return nil, fmt.Errorf("Attribute \"embedded\" has no value")
+	}
+	if strings.ContainsAny(rel, ",;") {
+		return nil, fmt.Errorf("Embedded \"%s\" cannot be combined with other attributes", rel)
+	}
+	switch field.Type.Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Slice:
+	default:
+		return nil, fmt.Errorf("Embedded \"%s\" cannot be %s", rel, field.Type)
+	}
+	if !field.IsExported() {
+		return nil, fmt.Errorf("Embedded \"%s\" is not exported", rel)
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		name = ""
+	case "":
+		name = field.Name
+	}
+	return &tagEmbedded{rel: rel, name: name, index: field.index}, nil
+}
+
+// parseLinkTag parses links declared in a hal struct tag
+func parseLinkTag(tag string, fields map[string][]int) ([]*tagLink, error) {
+	var links []*tagLink
+	for _, declaration := range splitTag(tag, ';') {
+		link := new(tagLink)
+		var href string
+		for _, attr := range splitTag(declaration, ',') {
+			key, value, hasValue := strings.Cut(strings.TrimSpace(attr), "=")
+			switch key {
+			case "templated", "omitempty":
+				if hasValue {
+					return nil, fmt.Errorf("Flag \"%s\" does not take a value", key)
+				}
+			case "link", "href", "title", "type", "name", "profile", "hreflang", "deprecation":
+				if !hasValue || value == "" {
+					return nil, fmt.Errorf("Attribute \"%s\" has no value", key)
+				}
+			default:
+				return nil, fmt.Errorf("Unknown attribute \"%s\"", key)
+			}
+			switch key {
+			case "templated":
+				link.templated = true
+				link.options = append(link.options, Templated())
+			case "omitempty":
+				link.omitempty = true
+			case "link":
+				link.rel = value
+			case "href":
+				href = value
+			case "title":
+				link.title = value
+			case "type":
+				link.options = append(link.options, MediaType(value))
+			case "name":
+				link.options = append(link.options, Name(value))
+			case "profile":
+				link.options = append(link.options, Profile(value))
+			case "hreflang":
+				link.options = append(link.options, Hreflang(value))
+			case "deprecation":
+				link.options = append(link.options, Deprecation(value))
+			}
+		}
+		if link.rel == "" {
+			return nil, fmt.Errorf("Link has no \"link\" attribute")
+		}
+		if href == "" {
+			return nil, fmt.Errorf("Link \"%s\" has no \"href\" attribute", link.rel)
+		}
+
+		var err error
+		if link.href, err = ParseURITemplate(href); err != nil {
+			return nil, fmt.Errorf("Link \"%s\": %w", link.rel, err)
+		}
+		link.fields = make(map[string][]int)
+		for _, name := range link.href.Variables() {
+			index, ok := fields[name]
+			if !ok {
+				if link.templated {
+					continue
+				}
+				return nil, fmt.Errorf("Link \"%s\": variable \"%s\" does not match any field", link.rel, name)
+			}
+			link.fields[name] = index
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
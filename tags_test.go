@@ -0,0 +1,113 @@
+package jsonhal
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type taggedAudit struct {
+	Version int `json:"version"`
+}
+
+type taggedOrder struct {
+	Hal `hal:"link=self,href=/v1/orders/{id},title=Order;link=search,href=/v1/orders{?q,page},templated"`
+	taggedAudit
+	ID         int      `json:"id"`
+	CustomerID string   `json:"customerId" hal:"link=customer,href=/v1/customers/{customerId},omitempty,type=application/hal+json"`
+	Tags       []string `json:"tags" hal:"link=tags,href=/v1/tags{/tags*},omitempty"`
+	Tag        string   `json:"-" hal:"link=history,href=/v1/orders/{id}/versions/{version}"`
+}
+
+type taggedShipment struct {
+	Hal `hal:"link=self,href=/v1/shipments/{id}"`
+	*taggedAudit
+	ID      *int `json:"id"`
+	Carrier *int `json:"carrier" hal:"link=carrier,href=/v1/carriers/{carrier},omitempty"`
+	Tracker int  `json:"-" hal:"link=history,href=/v1/shipments/{id}/versions/{version}"`
+}
+
+type invalidTagged struct {
+	Hal  `hal:"link=self,href=/v1/orders/{id}"`
+	Name string `json:"name"`
+}
+
+func TestSetTaggedLinks(t *testing.T) {
+	order := &taggedOrder{ID: 1, CustomerID: "bob", Tags: []string{"a", "b"}, taggedAudit: taggedAudit{Version: 2}}
+	assert.NoError(t, SetTaggedLinks(order))
+
+	actual, err := json.Marshal(order.Links)
+	assert.NoError(t, err)
+	expected := `{` +
+		`"customer":{"href":"/v1/customers/bob","type":"application/hal+json"},` +
+		`"history":{"href":"/v1/orders/1/versions/2"},` +
+		`"search":{"href":"/v1/orders{?q,page}","templated":true},` +
+		`"self":{"href":"/v1/orders/1","title":"Order"},` +
+		`"tags":{"href":"/v1/tags/a/b"}}`
+	assert.Equal(t, expected, string(actual))
+
+	// Links set before are replaced, links referring to zero
+	// values are skipped when marked omitempty
+	order.ID, order.CustomerID, order.Tags = 2, "", nil
+	order.AddLink("self", "/v1/bogus", "")
+	order.SetLink("next", "/v1/orders/3", "")
+	assert.NoError(t, SetTaggedLinks(order))
+	self, err := order.GetLinks("self")
+	assert.NoError(t, err)
+	assert.Equal(t, []*Link{{Href: "/v1/orders/2", Title: "Order"}}, self)
+	_, err = order.GetLink("customer")
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+	_, err = order.GetLink("tags")
+	assert.True(t, errors.Is(err, ErrLinkNotFound))
+	_, err = order.GetLink("next")
+	assert.NoError(t, err)
+}
+
+func TestSetTaggedLinksErrors(t *testing.T) {
+	// Tags are validated on first use and the error is cached
+	for i := 0; i < 2; i++ {
+		err := SetTaggedLinks(&invalidTagged{Name: "foo"})
+		assert.True(t, errors.Is(err, ErrInvalidTag))
+		assert.EqualError(
+			t,
+			err,
+			"Invalid hal tag of jsonhal.invalidTagged.Hal: Link \"self\": variable \"id\" does not match any field",
+		)
+	}
+
+	// Fields which are nil pointers or promoted through them are undefined
+	id := 1
+	err := SetTaggedLinks(&taggedShipment{})
+	assert.True(t, errors.Is(err, ErrMissingParameter))
+	assert.EqualError(t, err, "Link \"self\": missing parameter \"id\"")
+	err = SetTaggedLinks(&taggedShipment{ID: &id})
+	assert.EqualError(t, err, "Link \"history\": missing parameter \"version\"")
+	shipment := &taggedShipment{ID: &id, taggedAudit: &taggedAudit{Version: 2}}
+	assert.NoError(t, SetTaggedLinks(shipment))
+	actual, err := json.Marshal(shipment.Links)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"history":{"href":"/v1/shipments/1/versions/2"},"self":{"href":"/v1/shipments/1"}}`, string(actual))
+
+	err = SetTaggedLinks(invalidTagged{})
+	assert.EqualError(t, err, "Value of type jsonhal.invalidTagged is not a pointer to a struct embedding Hal")
+
+	fields := map[string][]int{"id": {0}}
+	testCases := []struct {
+		tag      string
+		expected string
+	}{
+		{"href=/v1/orders", "Link has no \"link\" attribute"},
+		{"link=self", "Link \"self\" has no \"href\" attribute"},
+		{"link=self,href=/v1/orders/{id", "Link \"self\": Unclosed expression in URI template \"/v1/orders/{id\""},
+		{"link=self,href=/v1/orders,color=red", "Unknown attribute \"color\""},
+		{"link=self,href=/v1/orders,templated=true", "Flag \"templated\" does not take a value"},
+		{"link=self,href=/v1/orders,title", "Attribute \"title\" has no value"},
+		{"link=self,href=/v1/orders/{id};link=next", "Link \"next\" has no \"href\" attribute"},
+	}
+	for _, testCase := range testCases {
+		_, err := parseLinkTag(testCase.tag, fields)
+		assert.EqualError(t, err, testCase.expected, testCase.tag)
+	}
+}
@@ -0,0 +1,432 @@
+package jsonhal
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// URITemplate represents a parsed RFC 6570 URI template, all four levels
+// of the specification are supported.
+// URI Template specification: https://tools.ietf.org/html/rfc6570
+type URITemplate struct {
+	raw   string
+	parts []templatePart
+}
+
+// templatePart is either a literal string or an expression
+type templatePart struct {
+	literal  string
+	operator *templateOperator
+	varspecs []templateVarspec
+}
+
+// templateVarspec is a single variable inside of an expression
+type templateVarspec struct {
+	name      string
+	maxLength int
+	explode   bool
+}
+
+// templateOperator describes how expression variables are expanded
+type templateOperator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+}
+
+var templateOperators = map[byte]*templateOperator{
+	'+': {first: "", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+	'#': {first: "#", sep: ",", reserved: true},
+}
+
+var simpleOperator = &templateOperator{first: "", sep: ","}
+
+// ParseURITemplate parses a URI template or returns an error
+// if the template is malformed
+func ParseURITemplate(template string) (*URITemplate, error) {
+	t := &URITemplate{raw: template}
+	for rest := template; rest != ""; {
+		start := strings.IndexAny(rest, "{}")
+		if start == -1 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		if rest[start] == '}' {
+			return nil, fmt.Errorf("Unexpected \"}\" in URI template \"%s\"", template)
+		}
+		if start > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:start]})
+		}
+		end := strings.IndexAny(rest[start+1:], "{}")
+		if end == -1 || rest[start+1+end] == '{' {
+			return nil, fmt.Errorf("Unclosed expression in URI template \"%s\"", template)
+		}
+		part, err := parseExpression(rest[start+1 : start+1+end])
+		if err != nil {
+			return nil, fmt.Errorf("%s in URI template \"%s\"", err, template)
+		}
+		t.parts = append(t.parts, part)
+		rest = rest[start+1+end+1:]
+	}
+	return t, nil
+}
+
+func parseExpression(expression string) (templatePart, error) {
+	part := templatePart{operator: simpleOperator}
+	if expression == "" {
+		return part, fmt.Errorf("Empty expression")
+	}
+	if operator, ok := templateOperators[expression[0]]; ok {
+		part.operator = operator
+		expression = expression[1:]
+	} else if strings.IndexByte("=,!@|", expression[0]) != -1 {
+		return part, fmt.Errorf("Reserved operator \"%c\"", expression[0])
+	}
+	for _, spec := range strings.Split(expression, ",") {
+		varspec := templateVarspec{name: spec}
+		if strings.HasSuffix(spec, "*") {
+			varspec.name, varspec.explode = spec[:len(spec)-1], true
+		} else if i := strings.IndexByte(spec, ':'); i != -1 {
+			maxLength, err := strconv.Atoi(spec[i+1:])
+			if err != nil || maxLength < 1 || maxLength > 9999 || spec[i+1] == '0' {
+				return part, fmt.Errorf("Invalid prefix modifier \"%s\"", spec[i:])
+			}
+			varspec.name, varspec.maxLength = spec[:i], maxLength
+		}
+		if !validVarname(varspec.name) {
+			return part, fmt.Errorf("Invalid variable name \"%s\"", varspec.name)
+		}
+		part.varspecs = append(part.varspecs, varspec)
+	}
+	return part, nil
+}
+
+// validVarname checks a variable name consists of varchars
+// optionally separated by single dots
+func validVarname(name string) bool {
+	if name == "" || name[0] == '.' || name[len(name)-1] == '.' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case isAlpha(c), isDigit(c), c == '_':
+		case c == '.':
+			if name[i-1] == '.' {
+				return false
+			}
+		case c == '%':
+			if i+2 >= len(name) || !isHex(name[i+1]) || !isHex(name[i+2]) {
+				return false
+			}
+			i += 2
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the original template
+func (t *URITemplate) String() string {
+	return t.raw
+}
+
+// Variables returns names of all variables used in the template
+// in order of their first appearance
+func (t *URITemplate) Variables() []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, part := range t.parts {
+		for _, varspec := range part.varspecs {
+			if !seen[varspec.name] {
+				seen[varspec.name] = true
+				names = append(names, varspec.name)
+			}
+		}
+	}
+	return names
+}
+
+// Expand expands the template using values. A value can be a string or any
+// other scalar, a slice (list value) or a map (associative array value,
+// expanded with keys sorted). Missing and nil values are undefined
+func (t *URITemplate) Expand(values map[string]interface{}) (string, error) {
+	buf := new(bytes.Buffer)
+	for _, part := range t.parts {
+		if part.operator == nil {
+			encode(buf, part.literal, true)
+			continue
+		}
+		if err := part.expand(buf, values); err != nil {
+			return "", fmt.Errorf("%s in URI template \"%s\"", err, t.raw)
+		}
+	}
+	return buf.String(), nil
+}
+
+func (part templatePart) expand(buf *bytes.Buffer, values map[string]interface{}) error {
+	op := part.operator
+	first := true
+	for _, varspec := range part.varspecs {
+		value, err := newTemplateValue(values[varspec.name])
+		if err != nil {
+			return fmt.Errorf("Variable \"%s\": %s", varspec.name, err)
+		}
+		if value.undefined() {
+			continue
+		}
+		if first {
+			buf.WriteString(op.first)
+			first = false
+		} else {
+			buf.WriteString(op.sep)
+		}
+
+		switch {
+		case value.list == nil && value.keys == nil:
+			s := value.scalar
+			if op.named {
+				buf.WriteString(varspec.name)
+				if s == "" {
+					buf.WriteString(op.ifEmpty)
+					continue
+				}
+				buf.WriteByte('=')
+			}
+			if varspec.maxLength > 0 && utf8.RuneCountInString(s) > varspec.maxLength {
+				s = string([]rune(s)[:varspec.maxLength])
+			}
+			encode(buf, s, op.reserved)
+		case !varspec.explode:
+			if op.named {
+				buf.WriteString(varspec.name)
+				buf.WriteByte('=')
+			}
+			for i, item := range value.items() {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				encode(buf, item, op.reserved)
+			}
+		case value.list != nil:
+			for i, item := range value.list {
+				if i > 0 {
+					buf.WriteString(op.sep)
+				}
+				if op.named {
+					buf.WriteString(varspec.name)
+					if item == "" {
+						buf.WriteString(op.ifEmpty)
+						continue
+					}
+					buf.WriteByte('=')
+				}
+				encode(buf, item, op.reserved)
+			}
+		default:
+			for i, key := range value.keys {
+				if i > 0 {
+					buf.WriteString(op.sep)
+				}
+				encode(buf, key, op.reserved)
+				if op.named && value.assoc[key] == "" {
+					buf.WriteString(op.ifEmpty)
+					continue
+				}
+				buf.WriteByte('=')
+				encode(buf, value.assoc[key], op.reserved)
+			}
+		}
+	}
+	return nil
+}
+
+// templateValue is a variable value normalised to one of the three
+// value types defined by the specification
+type templateValue struct {
+	defined bool
+	scalar  string
+	list    []string
+	keys    []string
+	assoc   map[string]string
+}
+
+func newTemplateValue(value interface{}) (templateValue, error) {
+	if value == nil {
+		return templateValue{}, nil
+	}
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return templateValue{}, nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		list := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := scalarString(v.Index(i))
+			if err != nil {
+				return templateValue{}, err
+			}
+			list = append(list, item)
+		}
+		return templateValue{defined: true, list: list}, nil
+	case reflect.Map:
+		assoc := make(map[string]string, v.Len())
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			key, err := scalarString(k)
+			if err != nil {
+				return templateValue{}, err
+			}
+			item, err := scalarString(v.MapIndex(k))
+			if err != nil {
+				return templateValue{}, err
+			}
+			keys = append(keys, key)
+			assoc[key] = item
+		}
+		sort.Strings(keys)
+		return templateValue{defined: true, keys: keys, assoc: assoc}, nil
+	}
+	scalar, err := scalarString(v)
+	if err != nil {
+		return templateValue{}, err
+	}
+	return templateValue{defined: true, scalar: scalar}, nil
+}
+
+func scalarString(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String(), nil
+		}
+		v = v.Elem()
+	}
+	if v.CanInterface() {
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String(), nil
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("Unsupported value type %s", v.Type())
+}
+
+// undefined reports whether the value should be skipped,
+// empty lists and associative arrays are considered undefined
+func (v templateValue) undefined() bool {
+	if !v.defined {
+		return true
+	}
+	return (v.list != nil && len(v.list) == 0) || (v.keys != nil && len(v.keys) == 0)
+}
+
+// items returns list items or flattened key value pairs
+func (v templateValue) items() []string {
+	if v.list != nil {
+		return v.list
+	}
+	items := make([]string, 0, 2*len(v.keys))
+	for _, key := range v.keys {
+		items = append(items, key, v.assoc[key])
+	}
+	return items
+}
+
+// encode writes s percent encoding all characters except unreserved ones,
+// when reserved is true reserved characters and pct-encoded triplets
+// are written as they are as well
+func encode(buf *bytes.Buffer, s string, reserved bool) {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isUnreserved(c):
+			buf.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) != -1:
+			buf.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			buf.WriteString(s[i : i+3])
+			i += 2
+		default:
+			fmt.Fprintf(buf, "%%%02X", c)
+		}
+	}
+}
+
+func isAlpha(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func isUnreserved(c byte) bool {
+	return isAlpha(c) || isDigit(c) || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// Expand expands a templated link href using values, see URITemplate.Expand.
+// Href of a link which is not templated is returned as is
+func (l *Link) Expand(values map[string]interface{}) (string, error) {
+	if !l.Templated {
+		return l.Href, nil
+	}
+	t, err := ParseURITemplate(l.Href)
+	if err != nil {
+		return "", err
+	}
+	return t.Expand(values)
+}
+
+// Variables returns names of all variables used in a templated link href,
+// a link which is not templated has no variables
+func (l *Link) Variables() ([]string, error) {
+	if !l.Templated {
+		return []string{}, nil
+	}
+	t, err := ParseURITemplate(l.Href)
+	if err != nil {
+		return nil, err
+	}
+	return t.Variables(), nil
+}
@@ -0,0 +1,204 @@
+package jsonhal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Variables and examples from the RFC 6570 specification, associative
+// arrays are expanded with keys sorted so some examples are reordered
+var templateValues = map[string]interface{}{
+	"count":      []string{"one", "two", "three"},
+	"dom":        []string{"example", "com"},
+	"dub":        "me/too",
+	"hello":      "Hello World!",
+	"half":       "50%",
+	"var":        "value",
+	"who":        "fred",
+	"base":       "http://example.com/home/",
+	"path":       "/foo/bar",
+	"list":       []string{"red", "green", "blue"},
+	"keys":       map[string]string{"semi": ";", "dot": ".", "comma": ","},
+	"v":          6,
+	"x":          1024,
+	"y":          768,
+	"empty":      "",
+	"empty_keys": map[string]string{},
+	"undef":      nil,
+}
+
+var templateExpansions = []struct {
+	template string
+	expected string
+}{
+	// Level 1
+	{"{var}", "value"},
+	{"{hello}", "Hello%20World%21"},
+	{"{half}", "50%25"},
+	{"O{empty}X", "OX"},
+	{"O{undef}X", "OX"},
+	// Level 2
+	{"{+var}", "value"},
+	{"{+hello}", "Hello%20World!"},
+	{"{+half}", "50%25"},
+	{"{base}index", "http%3A%2F%2Fexample.com%2Fhome%2Findex"},
+	{"{+base}index", "http://example.com/home/index"},
+	{"{+path}/here", "/foo/bar/here"},
+	{"here?ref={+path}", "here?ref=/foo/bar"},
+	{"X{#var}", "X#value"},
+	{"X{#hello}", "X#Hello%20World!"},
+	// Level 3
+	{"map?{x,y}", "map?1024,768"},
+	{"{x,hello,y}", "1024,Hello%20World%21,768"},
+	{"{+x,hello,y}", "1024,Hello%20World!,768"},
+	{"{+path,x}/here", "/foo/bar,1024/here"},
+	{"{#x,hello,y}", "#1024,Hello%20World!,768"},
+	{"{#path,x}/here", "#/foo/bar,1024/here"},
+	{"X{.var}", "X.value"},
+	{"X{.x,y}", "X.1024.768"},
+	{"{/var}", "/value"},
+	{"{/var,x}/here", "/value/1024/here"},
+	{"{;x,y}", ";x=1024;y=768"},
+	{"{;x,y,empty}", ";x=1024;y=768;empty"},
+	{"{?x,y}", "?x=1024&y=768"},
+	{"{?x,y,empty}", "?x=1024&y=768&empty="},
+	{"?fixed=yes{&x}", "?fixed=yes&x=1024"},
+	{"{&x,y,empty}", "&x=1024&y=768&empty="},
+	{"{?x,undef,empty_keys}", "?x=1024"},
+	// Level 4
+	{"{var:3}", "val"},
+	{"{var:30}", "value"},
+	{"{list}", "red,green,blue"},
+	{"{list*}", "red,green,blue"},
+	{"{keys}", "comma,%2C,dot,.,semi,%3B"},
+	{"{keys*}", "comma=%2C,dot=.,semi=%3B"},
+	{"{+path:6}/here", "/foo/b/here"},
+	{"{+list}", "red,green,blue"},
+	{"{+list*}", "red,green,blue"},
+	{"{+keys}", "comma,,,dot,.,semi,;"},
+	{"{+keys*}", "comma=,,dot=.,semi=;"},
+	{"{#path:6}/here", "#/foo/b/here"},
+	{"{#list}", "#red,green,blue"},
+	{"{#list*}", "#red,green,blue"},
+	{"{#keys}", "#comma,,,dot,.,semi,;"},
+	{"{#keys*}", "#comma=,,dot=.,semi=;"},
+	{"X{.var:3}", "X.val"},
+	{"X{.list}", "X.red,green,blue"},
+	{"X{.list*}", "X.red.green.blue"},
+	{"X{.keys}", "X.comma,%2C,dot,.,semi,%3B"},
+	{"X{.keys*}", "X.comma=%2C.dot=..semi=%3B"},
+	{"{/var:1,var}", "/v/value"},
+	{"{/list}", "/red,green,blue"},
+	{"{/list*}", "/red/green/blue"},
+	{"{/list*,path:4}", "/red/green/blue/%2Ffoo"},
+	{"{/keys}", "/comma,%2C,dot,.,semi,%3B"},
+	{"{/keys*}", "/comma=%2C/dot=./semi=%3B"},
+	{"{;hello:5}", ";hello=Hello"},
+	{"{;list}", ";list=red,green,blue"},
+	{"{;list*}", ";list=red;list=green;list=blue"},
+	{"{;keys}", ";keys=comma,%2C,dot,.,semi,%3B"},
+	{"{;keys*}", ";comma=%2C;dot=.;semi=%3B"},
+	{"{?var:3}", "?var=val"},
+	{"{?list}", "?list=red,green,blue"},
+	{"{?list*}", "?list=red&list=green&list=blue"},
+	{"{?keys}", "?keys=comma,%2C,dot,.,semi,%3B"},
+	{"{?keys*}", "?comma=%2C&dot=.&semi=%3B"},
+	{"{&var:3}", "&var=val"},
+	{"{&list}", "&list=red,green,blue"},
+	{"{&list*}", "&list=red&list=green&list=blue"},
+	{"{&keys}", "&keys=comma,%2C,dot,.,semi,%3B"},
+	{"{&keys*}", "&comma=%2C&dot=.&semi=%3B"},
+	// Literals and unicode
+	{"/v1/hello world/{who}", "/v1/hello%20world/fred"},
+	{"{var:2}", "va"},
+	{"{?q:2}", "?q=%C3%A9t"},
+}
+
+func TestURITemplateExpand(t *testing.T) {
+	values := map[string]interface{}{"q": "été"}
+	for name, value := range templateValues {
+		values[name] = value
+	}
+	for _, expansion := range templateExpansions {
+		template, err := ParseURITemplate(expansion.template)
+		if !assert.NoError(t, err, expansion.template) {
+			continue
+		}
+		actual, err := template.Expand(values)
+		assert.NoError(t, err, expansion.template)
+		assert.Equal(t, expansion.expected, actual, expansion.template)
+		assert.Equal(t, expansion.template, template.String())
+	}
+}
+
+func TestParseURITemplateErrors(t *testing.T) {
+	invalidTemplates := map[string]string{
+		"/v1/{id":        "Unclosed expression in URI template \"/v1/{id\"",
+		"/v1/{i{d}":      "Unclosed expression in URI template \"/v1/{i{d}\"",
+		"/v1/id}":        "Unexpected \"}\" in URI template \"/v1/id}\"",
+		"/v1/{}":         "Empty expression in URI template \"/v1/{}\"",
+		"/v1/{=id}":      "Reserved operator \"=\" in URI template \"/v1/{=id}\"",
+		"/v1/{id:0}":     "Invalid prefix modifier \":0\" in URI template \"/v1/{id:0}\"",
+		"/v1/{id:10000}": "Invalid prefix modifier \":10000\" in URI template \"/v1/{id:10000}\"",
+		"/v1/{i-d}":      "Invalid variable name \"i-d\" in URI template \"/v1/{i-d}\"",
+		"/v1/{id..x}":    "Invalid variable name \"id..x\" in URI template \"/v1/{id..x}\"",
+		"/v1/{id,}":      "Invalid variable name \"\" in URI template \"/v1/{id,}\"",
+	}
+	for template, expected := range invalidTemplates {
+		parsed, err := ParseURITemplate(template)
+		assert.Nil(t, parsed, template)
+		assert.EqualError(t, err, expected, template)
+	}
+
+	// Test expanding an unsupported value
+	template, err := ParseURITemplate("/v1/{id}")
+	assert.NoError(t, err)
+	actual, err := template.Expand(map[string]interface{}{"id": struct{}{}})
+	assert.Equal(t, "", actual)
+	assert.EqualError(t, err, "Variable \"id\": Unsupported value type struct {} in URI template \"/v1/{id}\"")
+}
+
+func TestURITemplateVariables(t *testing.T) {
+	template, err := ParseURITemplate("/v1/{who}/orders{/id,who}{?offset,limit*}{&q:3}")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"who", "id", "offset", "limit", "q"}, template.Variables())
+
+	template, err = ParseURITemplate("/v1/orders")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, template.Variables())
+}
+
+func TestLinkExpand(t *testing.T) {
+	helloWorld := new(HelloWorld)
+	helloWorld.SetLink("find", "/v1/hello/world{?name,limit}", "", Templated())
+	helloWorld.SetLink("self", "/v1/hello/world{?name}", "")
+
+	link, err := helloWorld.GetLink("find")
+	assert.NoError(t, err)
+	variables, err := link.Variables()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"name", "limit"}, variables)
+	href, err := link.Expand(map[string]interface{}{"name": "Hello World", "limit": 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/hello/world?name=Hello%20World&limit=10", href)
+
+	// Links which are not templated are returned as they are
+	link, err = helloWorld.GetLink("self")
+	assert.NoError(t, err)
+	variables, err = link.Variables()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, variables)
+	href, err = link.Expand(map[string]interface{}{"name": "Hello World"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/hello/world{?name}", href)
+
+	// Test expanding a malformed template
+	link = &Link{Href: "/v1/hello/world{?name", Templated: true}
+	href, err = link.Expand(nil)
+	assert.Equal(t, "", href)
+	assert.EqualError(t, err, "Unclosed expression in URI template \"/v1/hello/world{?name\"")
+	variables, err = link.Variables()
+	assert.Nil(t, variables)
+	assert.Error(t, err)
+}
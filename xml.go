@@ -0,0 +1,419 @@
+package jsonhal
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+// XMLContentType is the media type of HAL XML responses
+const XMLContentType = "application/hal+xml"
+
+// XMLEncoder writes HAL XML documents to an output stream
+type XMLEncoder struct {
+	w      io.Writer
+	prefix string
+	indent string
+}
+
+// NewXMLEncoder returns a new encoder writing to w
+func NewXMLEncoder(w io.Writer) *XMLEncoder {
+	return &XMLEncoder{w: w}
+}
+
+// SetIndent sets the encoder to indent every element, see xml.Encoder.Indent
+func (e *XMLEncoder) SetIndent(prefix, indent string) {
+	e.prefix, e.indent = prefix, indent
+}
+
+// Encode writes v, usually a struct embedding Hal, as a HAL XML document.
+// The value is walked the same way as by encoding/json so json struct tags
+// are honoured and the same Go types serve both formats. The root resource
+// href is taken from its self link, links are written as link elements,
+// embedded resources as resource elements and state properties as elements
+// named after them
+func (e *XMLEncoder) Encode(v interface{}) error {
+	resource, err := toResource(v)
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(buf)
+	encoder.Indent(e.prefix, e.indent)
+	if err := writeXMLResource(encoder, resource, ""); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err = e.w.Write(buf.Bytes())
+	return err
+}
+
+// MarshalXML returns the HAL XML encoding of v, see XMLEncoder.Encode
+func MarshalXML(v interface{}) ([]byte, error) {
+	return MarshalXMLIndent(v, "", "")
+}
+
+// MarshalXMLIndent is like MarshalXML but indents every element
+func MarshalXMLIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	encoder := NewXMLEncoder(buf)
+	encoder.SetIndent(prefix, indent)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// toResource converts v into a generic resource
+func toResource(v interface{}) (*Resource, error) {
+	if resource, ok := v.(*Resource); ok {
+		return resource, nil
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	resource := NewResource()
+	if err := json.Unmarshal(data, resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+func writeXMLResource(encoder *xml.Encoder, resource *Resource, rel string) error {
+	start := xml.StartElement{Name: xml.Name{Local: "resource"}}
+	if rel != "" {
+		start.Attr = append(start.Attr, xmlAttr("rel", rel))
+	}
+	if self, err := resource.GetLink("self"); err == nil {
+		start.Attr = append(start.Attr, xmlAttr("href", self.Href))
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(resource.Links) {
+		if name == "self" {
+			continue
+		}
+		for _, link := range resource.Links[name].Links {
+			if err := writeXMLLink(encoder, name, link); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(resource.Embedded) {
+		switch embedded := resource.Embedded[name].(type) {
+		case *Resource:
+			if embedded == nil {
+				continue
+			}
+			if err := writeXMLResource(encoder, embedded, name); err != nil {
+				return err
+			}
+		case []*Resource:
+			for _, item := range embedded {
+				if item == nil {
+					continue
+				}
+				if err := writeXMLResource(encoder, item, name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, name := range resource.PropertyNames() {
+		value, _ := resource.GetProperty(name)
+		if err := writeXMLValue(encoder, name, value); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+func writeXMLLink(encoder *xml.Encoder, rel string, link *Link) error {
+	start := xml.StartElement{Name: xml.Name{Local: "link"}}
+	start.Attr = append(start.Attr, xmlAttr("rel", rel), xmlAttr("href", link.Href))
+	if link.Templated {
+		start.Attr = append(start.Attr, xmlAttr("templated", "true"))
+	}
+	for _, attr := range []struct{ name, value string }{
+		{"type", link.Type},
+		{"deprecation", link.Deprecation},
+		{"name", link.Name},
+		{"profile", link.Profile},
+		{"title", link.Title},
+		{"hreflang", link.Hreflang},
+	} {
+		if attr.value != "" {
+			start.Attr = append(start.Attr, xmlAttr(attr.name, attr.value))
+		}
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// writeXMLValue writes a generic JSON value as an element, objects are
+// written as nested elements and arrays as repeated elements
+func writeXMLValue(encoder *xml.Encoder, name string, value interface{}) error {
+	if items, ok := value.([]interface{}); ok {
+		for _, item := range items {
+			if err := writeXMLValue(encoder, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !validXMLName(name) {
+		return fmt.Errorf("Property \"%s\" is not a valid XML name", name)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	switch typed := value.(type) {
+	case nil:
+	case map[string]interface{}:
+		for _, key := range sortedKeys(typed) {
+			if err := writeXMLValue(encoder, key, typed[key]); err != nil {
+				return err
+			}
+		}
+	case string:
+		if err := encoder.EncodeToken(xml.CharData(typed)); err != nil {
+			return err
+		}
+	case bool:
+		if err := encoder.EncodeToken(xml.CharData(strconv.FormatBool(typed))); err != nil {
+			return err
+		}
+	default:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(typed))); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+func xmlAttr(name, value string) xml.Attr {
+	return xml.Attr{Name: xml.Name{Local: name}, Value: value}
+}
+
+// validXMLName checks name can be used as an element name
+func validXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r), r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// XMLDecoder reads HAL XML documents from an input stream
+type XMLDecoder struct {
+	decoder *xml.Decoder
+}
+
+// NewXMLDecoder returns a new decoder reading from r
+func NewXMLDecoder(r io.Reader) *XMLDecoder {
+	return &XMLDecoder{decoder: xml.NewDecoder(r)}
+}
+
+// Decode reads the next HAL XML document into a generic resource. XML has
+// no value types so state properties are decoded as strings, elements with
+// child elements as objects and repeated elements as arrays. A single
+// embedded resource is decoded into *Resource, repeated ones into
+// []*Resource. Malformed documents result in an error matching
+// ErrInvalidDocument, at the end of the stream it matches io.EOF as well
+func (d *XMLDecoder) Decode() (*Resource, error) {
+	for {
+		token, err := d.decoder.Token()
+		if err != nil {
+			return nil, invalidDocument(err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local != "resource" {
+				return nil, invalidDocument(fmt.Errorf("Unexpected element \"%s\", expected \"resource\"", start.Name.Local))
+			}
+			resource, err := d.decodeResource(start)
+			return resource, invalidDocument(err)
+		}
+	}
+}
+
+// UnmarshalXML decodes a HAL XML document into a generic resource,
+// see XMLDecoder.Decode
+func UnmarshalXML(data []byte) (*Resource, error) {
+	return NewXMLDecoder(bytes.NewReader(data)).Decode()
+}
+
+func (d *XMLDecoder) decodeResource(start xml.StartElement) (*Resource, error) {
+	resource := NewResource()
+	if href, ok := xmlAttrValue(start, "href"); ok {
+		resource.SetLink("self", href, "")
+	}
+	for {
+		token, err := d.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.EndElement:
+			return resource, nil
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "link":
+				if err := d.decodeLink(resource, t); err != nil {
+					return nil, err
+				}
+			case "resource":
+				if err := d.decodeEmbedded(resource, t); err != nil {
+					return nil, err
+				}
+			default:
+				value, err := d.decodeValue()
+				if err != nil {
+					return nil, err
+				}
+				existing, ok := resource.properties[t.Name.Local]
+				resource.SetProperty(t.Name.Local, appendXMLValue(existing, ok, value))
+			}
+		}
+	}
+}
+
+func (d *XMLDecoder) decodeLink(resource *Resource, start xml.StartElement) error {
+	rel, ok := xmlAttrValue(start, "rel")
+	if !ok {
+		return fmt.Errorf("Link element without rel attribute")
+	}
+	link := new(Link)
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "href":
+			link.Href = attr.Value
+		case "templated":
+			link.Templated = attr.Value == "true"
+		case "type":
+			link.Type = attr.Value
+		case "deprecation":
+			link.Deprecation = attr.Value
+		case "name":
+			link.Name = attr.Value
+		case "profile":
+			link.Profile = attr.Value
+		case "title":
+			link.Title = attr.Value
+		case "hreflang":
+			link.Hreflang = attr.Value
+		}
+	}
+	if resource.Links == nil {
+		resource.Links = make(map[string]LinkSet, 0)
+	}
+	set := resource.Links[rel]
+	set.Links = append(set.Links, link)
+	resource.Links[rel] = set
+	return d.decoder.Skip()
+}
+
+func (d *XMLDecoder) decodeEmbedded(resource *Resource, start xml.StartElement) error {
+	rel, ok := xmlAttrValue(start, "rel")
+	if !ok {
+		return fmt.Errorf("Embedded resource element without rel attribute")
+	}
+	embedded, err := d.decodeResource(start)
+	if err != nil {
+		return err
+	}
+	switch existing := resource.Embedded[rel].(type) {
+	case *Resource:
+		resource.SetEmbedded(rel, []*Resource{existing, embedded})
+	case []*Resource:
+		resource.SetEmbedded(rel, append(existing, embedded))
+	default:
+		resource.SetEmbedded(rel, embedded)
+	}
+	return nil
+}
+
+// decodeValue decodes the content of a property element
+func (d *XMLDecoder) decodeValue() (interface{}, error) {
+	text := new(bytes.Buffer)
+	var object map[string]interface{}
+	for {
+		token, err := d.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			value, err := d.decodeValue()
+			if err != nil {
+				return nil, err
+			}
+			if object == nil {
+				object = make(map[string]interface{})
+			}
+			existing, ok := object[t.Name.Local]
+			object[t.Name.Local] = appendXMLValue(existing, ok, value)
+		case xml.EndElement:
+			if object != nil {
+				return object, nil
+			}
+			return text.String(), nil
+		}
+	}
+}
+
+// appendXMLValue turns repeated elements into an array
+func appendXMLValue(existing interface{}, exists bool, value interface{}) interface{} {
+	if !exists {
+		return value
+	}
+	if items, ok := existing.([]interface{}); ok {
+		return append(items, value)
+	}
+	return []interface{}{existing, value}
+}
+
+func xmlAttrValue(start xml.StartElement, name string) (string, bool) {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,146 @@
+package jsonhal
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var orderXML = `<?xml version="1.0" encoding="UTF-8"?>
+<resource href="/v1/orders/1">
+  <link rel="curies" href="http://docs.acme.com/rels/{rel}" templated="true" name="acme"></link>
+  <link rel="item" href="/v1/items/1"></link>
+  <link rel="item" href="/v1/items/2" type="application/hal+xml" hreflang="en"></link>
+  <resource rel="acme:customer" href="/v1/customers/1">
+    <name>John</name>
+  </resource>
+  <resource rel="acme:product" href="/v1/products/1">
+    <sku>abc</sku>
+  </resource>
+  <resource rel="acme:product" href="/v1/products/2">
+    <sku>def</sku>
+  </resource>
+  <total>30</total>
+  <shipping>
+    <city>New York</city>
+    <zip>10001</zip>
+  </shipping>
+  <tag>red</tag>
+  <tag>blue</tag>
+  <note></note>
+</resource>`
+
+func TestMarshalXML(t *testing.T) {
+	helloWorld := &HelloWorld{ID: 1, Name: "Hello World"}
+	helloWorld.SetLink("self", "/v1/hello/world/1", "")
+	helloWorld.SetLink("next", "/v1/hello/world/2", "Next & last")
+	helloWorld.SetLink("search", "/v1/hello/world{?name}", "", Templated())
+	foobars := []*Foobar{
+		&Foobar{ID: 1, Name: "Foo bar 1"},
+		&Foobar{ID: 2, Name: "Foo bar 2"},
+	}
+	foobars[0].SetLink("self", "/v1/foo/bar/1", "")
+	foobars[1].SetLink("self", "/v1/foo/bar/2", "")
+	helloWorld.SetEmbedded("foobars", Embedded(foobars))
+
+	actual, err := MarshalXMLIndent(helloWorld, "", "\t")
+	assert.NoError(t, err)
+	assert.Equal(t, strings.TrimSuffix(expectedXML, "\n"), string(actual))
+
+	actual, err = MarshalXML(&HelloWorld{ID: 1, Name: "Hello World"})
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<resource><id>1</id><name>Hello World</name></resource>`, string(actual))
+
+	// Test nil embedded resources are left out
+	withNil := &HelloWorld{ID: 1, Name: "Hello World"}
+	withNil.SetEmbedded("foobars", []*Foobar{{ID: 1}, nil})
+	actual, err = MarshalXML(withNil)
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		`<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+			`<resource><resource rel="foobars"><id>1</id><name></name></resource><id>1</id><name>Hello World</name></resource>`,
+		string(actual),
+	)
+
+	// Test encoding errors
+	_, err = MarshalXML(func() {})
+	assert.Error(t, err)
+	_, err = MarshalXML(map[string]interface{}{"1st": true})
+	assert.EqualError(t, err, "Property \"1st\" is not a valid XML name")
+}
+
+func TestUnmarshalXML(t *testing.T) {
+	resource, err := UnmarshalXML([]byte(orderXML))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// Test links
+	link, err := resource.GetLink("self")
+	assert.NoError(t, err)
+	assert.Equal(t, "/v1/orders/1", link.Href)
+	links, err := resource.GetLinks("item")
+	assert.NoError(t, err)
+	assert.Equal(t, []*Link{
+		&Link{Href: "/v1/items/1"},
+		&Link{Href: "/v1/items/2", Type: "application/hal+xml", Hreflang: "en"},
+	}, links)
+	rel, err := resource.ExpandRel("acme:customer")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://docs.acme.com/rels/customer", rel)
+
+	// Test embedded resources
+	customer, err := GetEmbeddedAs[*Resource](resource, "acme:customer")
+	assert.NoError(t, err)
+	name, _ := customer.GetProperty("name")
+	assert.Equal(t, "John", name)
+	products, err := GetEmbeddedAs[[]*Resource](resource, "acme:product")
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+
+	// Test properties
+	assert.Equal(t, []string{"total", "shipping", "tag", "note"}, resource.PropertyNames())
+	total, _ := resource.GetProperty("total")
+	assert.Equal(t, "30", total)
+	shipping, _ := resource.GetProperty("shipping")
+	assert.Equal(t, map[string]interface{}{"city": "New York", "zip": "10001"}, shipping)
+	tags, _ := resource.GetProperty("tag")
+	assert.Equal(t, []interface{}{"red", "blue"}, tags)
+
+	// Test a round trip
+	buf := new(bytes.Buffer)
+	encoder := NewXMLEncoder(buf)
+	encoder.SetIndent("", "  ")
+	assert.NoError(t, encoder.Encode(resource))
+	assert.Equal(t, orderXML+"\n", buf.String())
+}
+
+func TestUnmarshalXMLErrors(t *testing.T) {
+	invalidDocuments := map[string]string{
+		`<order></order>`: "Unexpected element \"order\", expected \"resource\"",
+		`<resource><link href="/"></link></resource>`: "Link element without rel attribute",
+		`<resource><resource></resource></resource>`:  "Embedded resource element without rel attribute",
+		`<resource><id>1</resource>`:                  "XML syntax error on line 1: element <id> closed by </resource>",
+		`<<`:                                          "XML syntax error on line 1: expected element name after <",
+		``:                                            "EOF",
+	}
+	for document, expected := range invalidDocuments {
+		resource, err := UnmarshalXML([]byte(document))
+		assert.Nil(t, resource)
+		assert.True(t, errors.Is(err, ErrInvalidDocument), document)
+		assert.EqualError(t, err, expected)
+	}
+
+	// Test the end of a stream
+	decoder := NewXMLDecoder(strings.NewReader(`<resource></resource>`))
+	_, err := decoder.Decode()
+	assert.NoError(t, err)
+	_, err = decoder.Decode()
+	assert.True(t, errors.Is(err, io.EOF))
+	assert.True(t, errors.Is(err, ErrInvalidDocument))
+}